@@ -1,6 +1,7 @@
 package constants
 
 import (
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -89,6 +90,60 @@ type ParamsConfig struct {
 	WebhookEndpoint           string
 	WebhookData               string
 	PipelineId                string
+	ApplyMode                 string
+	DeploymentSetName         string
+	DeploymentSetNamespace    string
+	RequireApproval           bool
+	ApprovalTimeoutSeconds    int64
+	Hooks                     string
+	SmokeTest                 string
+	WebhookFormat             string
+	WebhookContentMode        string
+	TraceParent               string
+	LogFormat                 string
+	LogLevel                  string
+	Build                     string
+	Network                   string
+}
+
+// BuildSpec configures the kaniko job, it is the build of the DeploymentSet.
+type BuildSpec struct {
+	BuildArgsFromSecrets []string          `json:"buildArgsFromSecrets,omitempty"`
+	Args                 map[string]string `json:"args,omitempty"`
+	Target               string            `json:"target,omitempty"`
+	ContextPath          string            `json:"contextPath,omitempty"`
+	DockerfilePath       string            `json:"dockerfilePath,omitempty"`
+	Cache                BuildCacheSpec    `json:"cache,omitempty"`
+	Pod                  BuildPodSpec      `json:"pod,omitempty"`
+	Images               BuildImagesSpec   `json:"images,omitempty"`
+	KanikoFlags          []string          `json:"kanikoFlags,omitempty"`
+}
+
+type BuildImagesSpec struct {
+	Git    string `json:"git,omitempty"`
+	Kaniko string `json:"kaniko,omitempty"`
+	Warmer string `json:"warmer,omitempty"`
+	Agent  string `json:"agent,omitempty"`
+}
+
+// BuildPodSpec is the build pod of the DeploymentSet, with the cluster defaults already filled in.
+type BuildPodSpec struct {
+	Resources          corev1.ResourceRequirements `json:"resources,omitempty"`
+	TimeoutSeconds     *int64                      `json:"timeoutSeconds,omitempty"`
+	BackoffLimit       *int32                      `json:"backoffLimit,omitempty"`
+	NodeSelector       map[string]string           `json:"nodeSelector,omitempty"`
+	Tolerations        []corev1.Toleration         `json:"tolerations,omitempty"`
+	Affinity           *corev1.Affinity            `json:"affinity,omitempty"`
+	PriorityClassName  string                      `json:"priorityClassName,omitempty"`
+	WorkspaceSizeLimit *resource.Quantity          `json:"workspaceSizeLimit,omitempty"`
+}
+
+type BuildCacheSpec struct {
+	Enabled             bool   `json:"enabled,omitempty"`
+	Repo                string `json:"repo,omitempty"`
+	TTL                 string `json:"ttl,omitempty"`
+	WarmBaseImages      bool   `json:"warmBaseImages,omitempty"`
+	BaseImageCacheClaim string `json:"baseImageCacheClaim,omitempty"`
 }
 type SecretConfig struct {
 	Name        string `json:"name"`
@@ -107,6 +162,12 @@ const (
 	SourceGitlab                      = "gitlab"
 	SourceBitbucket                   = "bitbucket"
 	DockerConfigMountPath             = "/docker-config"
+	BuildSecretsMountPath             = "/run/secrets"
+	BuildArgCommitSha                 = "COMMIT_SHA"
+	BuildArgPipelineId                = "PIPELINE_ID"
+	DefaultGitImage                   = "alpine/git"
+	DefaultKanikoImage                = "gcr.io/kaniko-project/executor:latest"
+	DefaultWarmerImage                = "gcr.io/kaniko-project/warmer:latest"
 	CreatedKanikoJob                  = "CREATED_KANIKO_JOB"
 	WebhookTypeDeploymentStatusUpdate = "TYPE_DEPLOYMENT_STATUS_UPDATE"
 	DeploymentFailed                  = "DEPLOYMENT_FAILED"
@@ -114,4 +175,22 @@ const (
 	CreatedApplicationCrd             = "CREATED_APPLICATION_CRD"
 	SecretContentTypeFileMount        = "FILE_MOUNT"
 	SecretContentTypeKeyValue         = "KEY_VALUE"
+	WaitingForApproval                = "WAITING_FOR_APPROVAL"
+	DeploymentApproved                = "DEPLOYMENT_APPROVED"
+	ApprovalAnnotation                = "humalect.com/approval"
+	ApprovalAnnotationApproved        = "approved"
+	ApprovalAnnotationRejected        = "rejected"
+	DefaultApprovalTimeoutSeconds     = 86400
+	CloudEventSourceAgent             = "/humalect-core/agent"
+	ErrorCategorySourceAuth           = "SourceAuth"
+	ErrorCategoryRegistryAuth         = "RegistryAuth"
+	ErrorCategorySecretFetch          = "SecretFetch"
+	ErrorCategoryBuildFailed          = "BuildFailed"
+	ErrorCategoryApplyFailed          = "ApplyFailed"
+	SourceCodeTokenEnv                = "HUMALECT_SOURCE_CODE_TOKEN"
+	EcrCredentialsEnv                 = "HUMALECT_ECR_CREDENTIALS"
+	AcrCredentialsEnv                 = "HUMALECT_ACR_CREDENTIALS"
+	DockerHubCredentialsEnv           = "HUMALECT_DOCKER_HUB_CREDENTIALS"
+	AwsSecretCredentialsEnv           = "HUMALECT_AWS_SECRET_CREDENTIALS"
+	AzureVaultCredentialsEnv          = "HUMALECT_AZURE_VAULT_CREDENTIALS"
 )
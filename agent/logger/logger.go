@@ -0,0 +1,91 @@
+// Package logger is the structured logger of the agent. Every line carries the deploymentId, pipelineId,
+// commit and the step of the deployment that is being executed.
+package logger
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"github.com/Humalect/humalect-core/pkg/redact"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+var (
+	base    = zap.NewNop()
+	current atomic.Pointer[zap.SugaredLogger]
+)
+
+func init() {
+	current.Store(base.Sugar())
+}
+
+// Setup builds the logger. The format is json(default) or console and the level is one of debug,
+// info(default), warn or error, both fall back to the LOG_FORMAT and LOG_LEVEL variables when empty.
+func Setup(format string, level string, deploymentId string, pipelineId string, commitId string) error {
+	if format == "" {
+		format = os.Getenv("LOG_FORMAT")
+	}
+	if level == "" {
+		level = os.Getenv("LOG_LEVEL")
+	}
+
+	zapLevel := zapcore.InfoLevel
+	if level != "" {
+		if err := zapLevel.Set(level); err != nil {
+			return fmt.Errorf("invalid log level %q: %v", level, err)
+		}
+	}
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.EncodeTime = zapcore.RFC3339TimeEncoder
+	var encoder zapcore.Encoder
+	switch format {
+	case FormatJSON, "":
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	case FormatConsole:
+		encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	default:
+		return fmt.Errorf("invalid log format %q, expected %s or %s", format, FormatJSON, FormatConsole)
+	}
+
+	base = zap.New(zapcore.NewCore(encoder, zapcore.Lock(redactingWriter{os.Stdout}), zapLevel)).With(
+		zap.String("deploymentId", deploymentId),
+		zap.String("pipelineId", pipelineId),
+		zap.String("commit", commitId),
+	)
+	current.Store(base.Sugar())
+	return nil
+}
+
+// SetStep adds the step to every line logged until the next step starts.
+func SetStep(step string) {
+	current.Store(base.With(zap.String("step", step)).Sugar())
+}
+
+func Log() *zap.SugaredLogger {
+	return current.Load()
+}
+
+// Sync flushes the buffered lines, it has to be called before the agent exits.
+func Sync() {
+	_ = base.Sync()
+}
+
+// redactingWriter scrubs the registered secret values from the encoded lines.
+type redactingWriter struct {
+	zapcore.WriteSyncer
+}
+
+func (w redactingWriter) Write(line []byte) (int, error) {
+	if _, err := w.WriteSyncer.Write([]byte(redact.String(string(line)))); err != nil {
+		return 0, err
+	}
+	return len(line), nil
+}
@@ -1,17 +1,40 @@
 package main
 
 import (
+	"context"
 	"log"
 
+	"github.com/Humalect/humalect-core/agent/constants"
+	"github.com/Humalect/humalect-core/agent/logger"
+	"github.com/Humalect/humalect-core/agent/services"
 	"github.com/Humalect/humalect-core/agent/tasks"
 	"github.com/Humalect/humalect-core/agent/utils"
+	"github.com/Humalect/humalect-core/pkg/tracing"
 )
 
 // TODO handle all if err != nill with a webhook at backend and as this is going open source so the webhook should be configurable
 func main() {
 	config := utils.ParseCLIArguments()
-	err := tasks.Deploy(config)
-	if err != nil {
+	utils.RedactCredentials(config)
+	if err := logger.Setup(config.LogFormat, config.LogLevel, config.DeploymentId, config.PipelineId, config.CommitId); err != nil {
 		log.Fatal(err)
 	}
+	// The CA bundle is trusted before any client is created, a failure still ends the deployment with a webhook.
+	if err := services.SetupNetwork(*config); err != nil {
+		tasks.ReportSetupFailure(config, err)
+		logger.Log().Fatalw("Failed to set up the network settings", "category", utils.GetErrorCategory(err, constants.ErrorCategoryBuildFailed), "error", err)
+	}
+	shutdownTracing, err := tracing.Setup(context.Background(), "humalect-agent")
+	if err != nil {
+		logger.Log().Fatalw("Failed to set up tracing", "error", err)
+	}
+	err = tasks.Deploy(config)
+	// Fatalw exits without running deferred functions, so the spans and the log lines are flushed here.
+	if shutdownErr := shutdownTracing(context.Background()); shutdownErr != nil {
+		logger.Log().Errorw("Failed to flush traces", "error", shutdownErr)
+	}
+	logger.Sync()
+	if err != nil {
+		logger.Log().Fatalw("Deployment failed", "error", err)
+	}
 }
@@ -4,10 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"strings"
 
 	"github.com/Humalect/humalect-core/agent/constants"
+	"github.com/Humalect/humalect-core/agent/logger"
+	"github.com/Humalect/humalect-core/agent/utils"
 )
 
 func AddCustomDockerfile(UseDockerFromCodeFlag bool,
@@ -21,8 +22,8 @@ func AddCustomDockerfile(UseDockerFromCodeFlag bool,
 		var dockerCommands []string
 		err := json.Unmarshal([]byte(dockerManifest), &dockerCommands)
 		if err != nil {
-			log.Fatal("Error unmarshalling DockerManifest JSON: ", err)
-			return err
+			logger.Log().Errorw("Error unmarshalling DockerManifest JSON", "error", err)
+			return utils.NewDeploymentError(constants.ErrorCategoryBuildFailed, err)
 		}
 		dockerFileContent := strings.Join(dockerCommands, "\r\n")
 
@@ -31,8 +32,8 @@ func AddCustomDockerfile(UseDockerFromCodeFlag bool,
 			dockerFilePath := fmt.Sprintf("%s/%s", constants.TempDirectoryName, dockerFileName)
 			err = ioutil.WriteFile(dockerFilePath, []byte(dockerFileContent), 0o644)
 			if err != nil {
-				log.Fatal("Error writing Dockerfile: ", err)
-				return err
+				logger.Log().Errorw("Error writing Dockerfile", "error", err)
+				return utils.NewDeploymentError(constants.ErrorCategoryBuildFailed, err)
 			}
 		}
 	}
@@ -3,10 +3,11 @@ package aws
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 
 	"github.com/Humalect/humalect-core/agent/constants"
+	"github.com/Humalect/humalect-core/agent/logger"
 	"github.com/Humalect/humalect-core/agent/services/k8s"
+	"github.com/Humalect/humalect-core/agent/utils"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -15,8 +16,8 @@ func CreateEcrSecret(params constants.ParamsConfig, clientSet *kubernetes.Client
 	_ = json.Unmarshal([]byte(params.EcrCredentials), &ecrCredentials)
 	ecrToken, err := getEcrLoginToken(ecrCredentials.AccessKey, ecrCredentials.SecretKey, ecrCredentials.Region)
 	if err != nil {
-		log.Fatalf("Error getting ECR token: %v", err)
-		return "", err
+		logger.Log().Errorw("Error getting ECR token", "error", err)
+		return "", utils.NewDeploymentError(constants.ErrorCategoryRegistryAuth, err)
 	}
 	secretData := fmt.Sprintf(`{  
 			"auths": {  
@@ -26,7 +27,8 @@ func CreateEcrSecret(params constants.ParamsConfig, clientSet *kubernetes.Client
 				}  
 			}  
 		}`, ecrCredentials.RegistryUrl, ecrToken)
-	return k8s.CreateSecret(map[string]string{
+	secretName, err := k8s.CreateSecret(map[string]string{
 		".dockerconfigjson": secretData,
 	}, params, clientSet, "humalect")
+	return secretName, utils.NewDeploymentError(constants.ErrorCategoryApplyFailed, err)
 }
@@ -9,6 +9,9 @@ import (
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ecr"
+
+	"github.com/Humalect/humalect-core/agent/logger"
+	"github.com/Humalect/humalect-core/pkg/redact"
 )
 
 func getEcrLoginToken(accessKey string, secretKey string, region string) (string, error) {
@@ -20,7 +23,7 @@ func getEcrLoginToken(accessKey string, secretKey string, region string) (string
 		Credentials: credentials.NewStaticCredentials(accessKey, secretKey, ""),
 	})
 	if err != nil {
-		fmt.Println("Error creating session:", err)
+		logger.Log().Errorw("Error creating session", "error", err)
 		return "", err
 	}
 
@@ -28,16 +31,17 @@ func getEcrLoginToken(accessKey string, secretKey string, region string) (string
 
 	result, err := ecrClient.GetAuthorizationToken(&ecr.GetAuthorizationTokenInput{})
 	if err != nil {
-		fmt.Println("Error getting ECR authorization token:", err)
+		logger.Log().Errorw("Error getting ECR authorization token", "error", err)
 		return "", err
 	}
 
 	decodedToken, err := base64.StdEncoding.DecodeString(*result.AuthorizationData[0].AuthorizationToken)
 	if err != nil {
-		fmt.Println("Error decoding ECR authorization token:", err)
+		logger.Log().Errorw("Error decoding ECR authorization token", "error", err)
 		return "", err
 	}
 
 	password := strings.Split(string(decodedToken), ":")[1]
+	redact.Add(*result.AuthorizationData[0].AuthorizationToken, password)
 	return password, nil
 }
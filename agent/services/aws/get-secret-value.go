@@ -3,9 +3,10 @@ package aws
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
 
 	"github.com/Humalect/humalect-core/agent/constants"
+	"github.com/Humalect/humalect-core/agent/logger"
+	"github.com/Humalect/humalect-core/pkg/redact"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -27,7 +28,7 @@ func GetSecretValue(secretName, accessKey, secretKey, region string, cloudProvid
 			Credentials: credentials.NewStaticCredentials(accessKey, secretKey, ""),
 		})
 		if err != nil {
-			fmt.Println("Error creating session:", err)
+			logger.Log().Errorw("Error creating session", "error", err)
 			return map[string]string{}, err
 		}
 	} else {
@@ -35,7 +36,7 @@ func GetSecretValue(secretName, accessKey, secretKey, region string, cloudProvid
 			Region: aws.String(region),
 		})
 		if err != nil {
-			fmt.Println("Error creating session:", err)
+			logger.Log().Errorw("Error creating session", "error", err)
 			return map[string]string{}, err
 		}
 	}
@@ -49,7 +50,7 @@ func GetSecretValue(secretName, accessKey, secretKey, region string, cloudProvid
 	}
 	result, err := svc.GetSecretValue(input)
 	if err != nil {
-		fmt.Println("Error getting secret value:", err)
+		logger.Log().Errorw("Error getting secret value", "error", err)
 		return map[string]string{}, err
 	}
 
@@ -59,10 +60,11 @@ func GetSecretValue(secretName, accessKey, secretKey, region string, cloudProvid
 	var secretData map[string]string
 	err = json.Unmarshal([]byte(secretValue), &secretData)
 	if err != nil {
-		fmt.Println("Error unmarshalling JSON:", err)
+		logger.Log().Errorw("Error unmarshalling JSON", "error", err)
 		return map[string]string{}, err
 	}
 
+	redact.AddSecretData(secretData)
 	// Return the "dockerhub" key's value
 	return secretData, nil
 }
@@ -3,10 +3,11 @@ package azure
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 
 	"github.com/Humalect/humalect-core/agent/constants"
+	"github.com/Humalect/humalect-core/agent/logger"
 	"github.com/Humalect/humalect-core/agent/services/k8s"
+	"github.com/Humalect/humalect-core/agent/utils"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -16,8 +17,8 @@ func CreateAcrSecret(params constants.ParamsConfig, clientSet *kubernetes.Client
 	azureCreds, err := FetchAcrCreds(acrCredentials.ManagementScopeToken, acrCredentials.RegistryName,
 		acrCredentials.SubscriptionId, acrCredentials.ResourceGroupName)
 	if err != nil {
-		log.Fatalf("Error getting Azure ACR creds: %v", err)
-		return "", err
+		logger.Log().Errorw("Error getting Azure ACR creds", "error", err)
+		return "", utils.NewDeploymentError(constants.ErrorCategoryRegistryAuth, err)
 	}
 
 	secretData := fmt.Sprintf(`{  
@@ -28,7 +29,8 @@ func CreateAcrSecret(params constants.ParamsConfig, clientSet *kubernetes.Client
 				}  
 			}  
 		}`, acrCredentials.RegistryName, azureCreds.Username, azureCreds.Password)
-	return k8s.CreateSecret(map[string]string{
+	secretName, err := k8s.CreateSecret(map[string]string{
 		".dockerconfigjson": secretData,
 	}, params, clientSet, "humalect")
+	return secretName, utils.NewDeploymentError(constants.ErrorCategoryApplyFailed, err)
 }
@@ -7,6 +7,9 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strings"
+
+	"github.com/Humalect/humalect-core/agent/logger"
+	"github.com/Humalect/humalect-core/pkg/redact"
 )
 
 type AzureCreds struct {
@@ -34,8 +37,7 @@ func FetchAcrCreds(AzureManagementScopeToken string, AzureAcrRegistryName string
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		fmt.Println("Status Code: ", resp.StatusCode)
-		fmt.Println("resp", resp)
+		logger.Log().Errorw("Error getting Azure ACR creds", "status", resp.StatusCode)
 		return AzureCreds{}, errors.New("non-200 status code received when tried to get Creds for Azure ACR")
 	}
 
@@ -53,6 +55,7 @@ func FetchAcrCreds(AzureManagementScopeToken string, AzureAcrRegistryName string
 	if username == "" {
 		return AzureCreds{}, errors.New("unable to fetch credentials for ACR")
 	}
+	redact.Add(password)
 
 	return AzureCreds{
 		Username: username,
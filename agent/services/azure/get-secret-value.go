@@ -7,6 +7,8 @@ import (
 	"io/ioutil"
 	"net/http"
 	"time"
+
+	"github.com/Humalect/humalect-core/pkg/redact"
 )
 
 func GetSecretValue(azureVaultToken string, vaultName string, secretName string) (map[string]string, error) {
@@ -50,6 +52,7 @@ func GetSecretValue(azureVaultToken string, vaultName string, secretName string)
 	if err != nil {
 		return map[string]string{}, fmt.Errorf("error unmarshalling response JSON: %v", err)
 	}
+	redact.AddSecretData(secretData)
 	// secretValue, ok := responseJSON["value"].(string)
 	// if !ok {
 	// 	return map[string]string{}, fmt.Errorf("value not found in response JSON")
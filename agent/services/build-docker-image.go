@@ -2,9 +2,9 @@ package services
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/Humalect/humalect-core/agent/constants"
+	"github.com/Humalect/humalect-core/agent/logger"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/client"
@@ -15,19 +15,19 @@ func BuildDockerImage(artifactsRepositoryName string,
 	artifactsRepoLink string,
 	commitId string,
 ) error {
-	fmt.Println("Building Docker image for:", artifactsRepositoryName, commitId)
+	logger.Log().Infow("Building docker image", "repository", artifactsRepositoryName, "commit", commitId)
 
 	ctx := context.Background()
 	cli, err := client.NewClientWithOpts(client.FromEnv)
 	if err != nil {
-		fmt.Println(err)
+		logger.Log().Errorw("Error creating docker client", "error", err)
 		return err
 	}
 	cli.NegotiateAPIVersion(ctx)
 
 	buildContext, err := archive.TarWithOptions(constants.TempDirectoryName, &archive.TarOptions{})
 	if err != nil {
-		fmt.Println(err)
+		logger.Log().Errorw("Error creating build context", "error", err)
 		return err
 	}
 
@@ -35,9 +35,7 @@ func BuildDockerImage(artifactsRepositoryName string,
 		Tags: []string{artifactsRepoLink},
 	})
 	if err != nil {
-		fmt.Println("After image build")
-
-		fmt.Println(err)
+		logger.Log().Errorw("Error building docker image", "error", err)
 		return err
 	}
 	defer imageBuildResponse.Body.Close()
@@ -2,11 +2,11 @@ package services
 
 import (
 	"context"
-	"fmt"
-	"log"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+
+	"github.com/Humalect/humalect-core/agent/logger"
 )
 
 // TODO send webhook here
@@ -14,7 +14,7 @@ func CleanupKanikoJobResources(jobConfig CreateJobConfig) error {
 	config := GetK8sConfig()
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		log.Fatalf("Error creating clientset: %v", err)
+		logger.Log().Errorw("Error creating clientset", "error", err)
 		// SendWebhook(params.WebhookEndpoint, params.WebhookData, false, constants.CreatedKanikoJob)
 		// panic(err)
 		return err
@@ -25,6 +25,15 @@ func CleanupKanikoJobResources(jobConfig CreateJobConfig) error {
 	// 		return err
 	// 	}
 	// }
+	// The git token and the build secrets are only read by the kaniko job.
+	for _, secretName := range []string{jobConfig.GitCredentialsSecretName, jobConfig.BuildSecretsName} {
+		if len(secretName) != 0 {
+			err := deleteSecret(clientset, secretName, "humalect")
+			if err != nil {
+				return err
+			}
+		}
+	}
 	if len(jobConfig.DockerFileConfigName) != 0 {
 		err := deleteConfigMap(clientset, jobConfig.DockerFileConfigName, "humalect")
 		if err != nil {
@@ -46,7 +55,7 @@ func deleteSecret(clientset *kubernetes.Clientset, secretName, namespace string)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("Secret '%s' in namespace '%s' deleted successfully.\n", secretName, namespace)
+	logger.Log().Infow("Deleted secret", "secret", secretName, "namespace", namespace)
 	return nil
 }
 
@@ -62,6 +71,6 @@ func deleteConfigMap(clientset *kubernetes.Clientset, configMapName, namespace s
 	if err != nil {
 		return err
 	}
-	fmt.Printf("ConfigMap '%s' in namespace '%s' deleted successfully.\n", configMapName, namespace)
+	logger.Log().Infow("Deleted configmap", "configMap", configMapName, "namespace", namespace)
 	return nil
 }
@@ -6,6 +6,7 @@ import (
 	"os/exec"
 
 	"github.com/Humalect/humalect-core/agent/constants"
+	"github.com/Humalect/humalect-core/agent/logger"
 )
 
 func CloneSourceCode(sourceCodeProvider string,
@@ -14,7 +15,7 @@ func CloneSourceCode(sourceCodeProvider string,
 	commitId string,
 	sourceCodeToken string,
 ) (string, error) {
-	fmt.Println("Started Cloning of source code.")
+	logger.Log().Infow("Started cloning of source code", "sourceCodeProvider", sourceCodeProvider, "repository", sourceCodeRepositoryName)
 
 	var repoArchiveURL string
 	switch sourceCodeProvider {
@@ -29,15 +30,15 @@ func CloneSourceCode(sourceCodeProvider string,
 	}
 
 	command := fmt.Sprintf("mkdir %s || true && (curl -L -k \"%s\" -H \"Authorization: Bearer %s\" | tar -xz -C %s --strip-components 1)", constants.TempDirectoryName, repoArchiveURL, sourceCodeToken, constants.TempDirectoryName)
-	fmt.Println(command)
-	fmt.Println("Cloning to temp folder")
+	// The command carries the source code token, only the archive URL is logged.
+	logger.Log().Infow("Cloning to temp folder", "archive", repoArchiveURL, "directory", constants.TempDirectoryName)
 	cmd := exec.Command("sh", "-c", command)
 
 	stdoutStderr, err := cmd.CombinedOutput()
 	if err != nil {
-		fmt.Println(string(stdoutStderr))
+		logger.Log().Errorw("Error cloning source code", "output", string(stdoutStderr), "error", err)
 		return "", err
 	}
-	fmt.Println("Cloning execution complete")
+	logger.Log().Info("Cloning execution complete")
 	return repoArchiveURL, nil
 }
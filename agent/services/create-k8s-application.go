@@ -4,9 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"flag"
-	"fmt"
 
 	"github.com/Humalect/humalect-core/agent/constants"
+	"github.com/Humalect/humalect-core/agent/logger"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -16,7 +16,7 @@ import (
 	"k8s.io/client-go/dynamic"
 )
 
-func CreateK8sApplication(params *constants.ParamsConfig, kanikoJobResources CreateJobConfig, webhookData string) (string, error) {
+func CreateK8sApplication(params *constants.ParamsConfig, kanikoJobResources CreateJobConfig, webhookData string, traceParent string) (string, error) {
 	var awsSecretCredentials constants.AwsSecretCredentials
 	var azureVaultCredentials constants.AzureVaultCredentials
 	var deploymentYamlManifest constants.DeploymentYamlManifestType
@@ -24,6 +24,8 @@ func CreateK8sApplication(params *constants.ParamsConfig, kanikoJobResources Cre
 	var ingressYamlManifest constants.IngressYamlManifestType
 	var buildSecretsConfig []constants.SecretConfig
 	var applicationSecretsConfig []constants.SecretConfig
+	var hooks map[string]interface{}
+	var smokeTest map[string]interface{}
 	json.Unmarshal([]byte(params.AwsSecretCredentials), &awsSecretCredentials)
 	json.Unmarshal([]byte(params.AzureVaultCredentials), &azureVaultCredentials)
 	json.Unmarshal([]byte(params.DeploymentYamlManifest), &deploymentYamlManifest)
@@ -31,6 +33,8 @@ func CreateK8sApplication(params *constants.ParamsConfig, kanikoJobResources Cre
 	json.Unmarshal([]byte(params.IngressYamlManifest), &ingressYamlManifest)
 	json.Unmarshal([]byte(params.BuildSecretsConfig), &buildSecretsConfig)
 	json.Unmarshal([]byte(params.ApplicationSecretsConfig), &applicationSecretsConfig)
+	json.Unmarshal([]byte(params.Hooks), &hooks)
+	json.Unmarshal([]byte(params.SmokeTest), &smokeTest)
 
 	deploymentYamlManifest.Spec.Template.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: kanikoJobResources.CloudProviderSecretName}}
 
@@ -88,6 +92,13 @@ func CreateK8sApplication(params *constants.ParamsConfig, kanikoJobResources Cre
 				"webhookEndpoint":          params.WebhookEndpoint,
 				"webhookData":              webhookData,
 				"pipelineId":               params.PipelineId,
+				"deploymentId":             params.DeploymentId,
+				"applyMode":                params.ApplyMode,
+				"hooks":                    hooks,
+				"smokeTest":                smokeTest,
+				"webhookFormat":            params.WebhookFormat,
+				"webhookContentMode":       params.WebhookContentMode,
+				"traceParent":              traceParent,
 			},
 		},
 	}
@@ -99,7 +110,7 @@ func CreateK8sApplication(params *constants.ParamsConfig, kanikoJobResources Cre
 		if errors.IsNotFound(err) {
 			applicationResource, err := dynamicClient.Resource(applicationGVR).Namespace(params.Namespace).Create(ctx, applicationInstance, metav1.CreateOptions{})
 			if err != nil {
-				fmt.Println(err)
+				logger.Log().Errorw("Error creating Application", "application", applicationInstance.GetName(), "error", err)
 				return "", err
 				// panic(err.Error())
 			}
@@ -114,10 +125,10 @@ func CreateK8sApplication(params *constants.ParamsConfig, kanikoJobResources Cre
 	}
 	updatedResource, err := dynamicClient.Resource(applicationGVR).Namespace(params.Namespace).Update(ctx, existingResource, metav1.UpdateOptions{})
 	if err != nil {
-		fmt.Println(err)
+		logger.Log().Errorw("Error updating Application", "application", applicationInstance.GetName(), "error", err)
 		return "", err
 		// panic(err.Error())
 	}
-	fmt.Printf("Created custom resource %s in namespace %s\n", updatedResource.GetName(), params.Namespace)
+	logger.Log().Infow("Updated Application", "application", updatedResource.GetName(), "namespace", params.Namespace)
 	return updatedResource.GetName(), nil
 }
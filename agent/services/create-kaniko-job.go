@@ -5,15 +5,21 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"math"
+	"path"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/Humalect/humalect-core/agent/constants"
+	"github.com/Humalect/humalect-core/agent/logger"
 	"github.com/Humalect/humalect-core/agent/services/aws"
 	"github.com/Humalect/humalect-core/agent/services/azure"
 	"github.com/Humalect/humalect-core/agent/services/dockerhub"
+	"github.com/Humalect/humalect-core/agent/services/k8s"
 	"github.com/Humalect/humalect-core/agent/utils"
+	"github.com/Humalect/humalect-core/pkg/netconfig"
 
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -23,9 +29,13 @@ import (
 )
 
 type CreateJobConfig struct {
-	CloudProviderSecretName string
-	DockerFileConfigName    string
-	KanikoJobName           string
+	CloudProviderSecretName  string
+	DockerFileConfigName     string
+	GitCredentialsSecretName string
+	BuildSecretsName         string
+	BuildArgNames            []string
+	KanikoJobName            string
+	ImageReference           string
 }
 
 const (
@@ -35,35 +45,47 @@ const (
 	kanikoDockerConfigName          = "kaniko-docker-config"
 	cloudProviderRegistrySecretName = "cloud-provider-registry-secret"
 	gitRepoVolumeName               = "git-repo"
+	gitTokenEnvName                 = "GIT_TOKEN"
+	gitCredentialsSecretKey         = "token"
+	buildSecretsVolumeName          = "build-secrets"
+	kanikoCacheVolumeName           = "kaniko-cache"
+	kanikoCacheDirectory            = "/cache"
+	kanikoCABundleFile              = "/kaniko/ssl/certs/humalect-ca.crt"
+	gitCABundleFile                 = "/tmp/ca-certificates.crt"
 )
 
+// PrepareConfigContainerName is the init container that clones the source code into the workspace.
+const PrepareConfigContainerName = "prepare-config"
+
+// CreateKanikoJob creates the kaniko job together with the registry secret and the Dockerfile ConfigMap
+// it uses. The resources created so far are returned with the error so they can still be cleaned up.
 func CreateKanikoJob(params constants.ParamsConfig) (CreateJobConfig, error) {
 	config := GetK8sConfig()
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		log.Fatalf("Error creating clientset: %v", err)
-		SendWebhook(params.WebhookEndpoint, params.WebhookData, false, constants.CreatedKanikoJob)
-		return CreateJobConfig{}, errors.New("Error Starting Build")
+		logger.Log().Errorw("Error creating clientset", "error", err)
+		return CreateJobConfig{}, utils.NewDeploymentError(constants.ErrorCategoryApplyFailed, err)
 	}
 	createJobConfig, err := createKanikoConfigResources(clientset, params)
 	if err != nil {
-		log.Fatalf("Error creating resources for Job: %v", err)
-		SendWebhook(params.WebhookEndpoint, params.WebhookData, false, constants.CreatedKanikoJob)
-		return CreateJobConfig{}, errors.New("Error Starting Build")
+		logger.Log().Errorw("Error creating resources for Job", "error", err)
+		return createJobConfig, err
 	}
 
+	createJobConfig.ImageReference, err = getArtifactsRepoUrl(params)
+	if err != nil {
+		return createJobConfig, utils.NewDeploymentError(constants.ErrorCategoryRegistryAuth, err)
+	}
 	job, err := getKanikoJobObject(createJobConfig, params)
 	if err != nil {
-		log.Fatalf("Error generating Job Yaml: %v", err)
-		SendWebhook(params.WebhookEndpoint, params.WebhookData, false, constants.CreatedKanikoJob)
-		return CreateJobConfig{}, errors.New("Error Starting Build")
+		logger.Log().Errorw("Error generating Job Yaml", "error", err)
+		return createJobConfig, err
 	}
 
 	jobClient := clientset.BatchV1().Jobs("humalect")
 	createdJob, err := jobClient.Create(context.Background(), &job, metav1.CreateOptions{})
 	if err != nil {
-		SendWebhook(params.WebhookEndpoint, params.WebhookData, false, constants.CreatedKanikoJob)
-		return CreateJobConfig{}, errors.New("Error Starting Build")
+		return createJobConfig, utils.NewDeploymentError(constants.ErrorCategoryApplyFailed, err)
 	}
 	SendWebhook(params.WebhookEndpoint, params.WebhookData, true, constants.CreatedKanikoJob)
 	createJobConfig.KanikoJobName = createdJob.GetName()
@@ -81,19 +103,23 @@ func createArtifactsSecret(clientset *kubernetes.Clientset, params constants.Par
 	return "", nil
 }
 
-func getCodeSourceSpecificGitUrl(params constants.ParamsConfig) string {
+// gitTokenReference is expanded by the shell of the prepare-config container, the token itself is read
+// from a Secret so it does not show up in the Job spec.
+const gitTokenReference = "${" + gitTokenEnvName + "}"
+
+func getCodeSourceSpecificGitUrl(params constants.ParamsConfig) (string, error) {
 	gitRepoUrl := ""
 	switch params.SourceCodeProvider {
 	case constants.SourceGithub:
-		gitRepoUrl = fmt.Sprintf("https://x-access-token:%s@github.com/%s.git", params.SourceCodeToken, params.SourceCodeRepositoryName)
+		gitRepoUrl = fmt.Sprintf("https://x-access-token:%s@github.com/%s.git", gitTokenReference, params.SourceCodeRepositoryName)
 	case constants.SourceGitlab:
-		gitRepoUrl = strings.Replace(params.SourceCodeRepositoryName, "https://", fmt.Sprintf("https://oauth2:%s@", params.SourceCodeToken), 1)
+		gitRepoUrl = strings.Replace(params.SourceCodeRepositoryName, "https://", fmt.Sprintf("https://oauth2:%s@", gitTokenReference), 1)
 	case constants.SourceBitbucket:
-		gitRepoUrl = fmt.Sprintf("https://x-token-auth:%s@bitbucket.org/%s.git", params.SourceCodeToken, params.SourceCodeRepositoryName)
+		gitRepoUrl = fmt.Sprintf("https://x-token-auth:%s@bitbucket.org/%s.git", gitTokenReference, params.SourceCodeRepositoryName)
 	default:
-		log.Fatal("Invalid Source Code Provider received.")
+		return "", utils.NewDeploymentError(constants.ErrorCategorySourceAuth, fmt.Errorf("invalid source code provider %q received", params.SourceCodeProvider))
 	}
-	return gitRepoUrl
+	return gitRepoUrl, nil
 }
 
 func getDockerFileConfig(clientset *kubernetes.Clientset, params constants.ParamsConfig) (string, error) {
@@ -101,8 +127,8 @@ func getDockerFileConfig(clientset *kubernetes.Clientset, params constants.Param
 		var dockerCommands []string
 		err := json.Unmarshal([]byte(params.DockerManifest), &dockerCommands)
 		if err != nil {
-			log.Fatalf("Failed to parse Dockerfile got error : %v", err)
-			return "", err
+			logger.Log().Errorw("Failed to parse Dockerfile", "error", err)
+			return "", utils.NewDeploymentError(constants.ErrorCategoryBuildFailed, err)
 		}
 
 		dockerFileContent := strings.Join(dockerCommands, "\r\n")
@@ -133,8 +159,8 @@ func getDockerFileConfig(clientset *kubernetes.Clientset, params constants.Param
 		}
 		configMap, err = clientset.CoreV1().ConfigMaps("humalect").Create(context.Background(), configMap, metav1.CreateOptions{})
 		if err != nil {
-			fmt.Println("Error creating ConfigMap:", err)
-			return "", err
+			logger.Log().Errorw("Error creating ConfigMap", "error", err)
+			return "", utils.NewDeploymentError(constants.ErrorCategoryApplyFailed, err)
 		}
 		return configMap.Name, nil
 
@@ -142,10 +168,7 @@ func getDockerFileConfig(clientset *kubernetes.Clientset, params constants.Param
 	return "", nil
 }
 
-func getKanikoJobObject(
-	createJobConfig CreateJobConfig,
-	params constants.ParamsConfig,
-) (batchv1.Job, error) {
+func getArtifactsRepoUrl(params constants.ParamsConfig) (string, error) {
 	var artifactsRepoUrl string
 	var imageTag=utils.MergeParseString(params.CommitId, params.PipelineId, 30)
 	if params.ArtifactsRegistryProvider == constants.RegistryIdAzure || (params.ArtifactsRegistryProvider == "" && params.CloudProvider == constants.CloudIdAzure) {
@@ -164,10 +187,29 @@ func getKanikoJobObject(
 		artifactsRepoUrl = fmt.Sprintf("%s/%s:%s", dockerHubCreds.Username, params.ArtifactsRepositoryName, imageTag)
 
 	} else {
-		fmt.Println("Invalid Artifacts Registry Provider received.")
-		return batchv1.Job{}, errors.New("Invalid Artifacts Registry Provider received.")
+		logger.Log().Errorw("Invalid Artifacts Registry Provider received.", "artifactsRegistryProvider", params.ArtifactsRegistryProvider)
+		return "", errors.New("Invalid Artifacts Registry Provider received.")
+	}
+	return artifactsRepoUrl, nil
+}
+
+func getKanikoJobObject(
+	createJobConfig CreateJobConfig,
+	params constants.ParamsConfig,
+) (batchv1.Job, error) {
+	artifactsRepoUrl := createJobConfig.ImageReference
+	gitUrl, err := getCodeSourceSpecificGitUrl(params)
+	if err != nil {
+		return batchv1.Job{}, err
+	}
+	build, err := GetBuildSpec(params)
+	if err != nil {
+		return batchv1.Job{}, err
+	}
+	contextDirectory, dockerfile, err := getBuildPaths(build)
+	if err != nil {
+		return batchv1.Job{}, err
 	}
-	gitUrl := getCodeSourceSpecificGitUrl(params)
 	prepareConfigVolumeMounts := []corev1.VolumeMount{
 		{
 			Name:      gitRepoVolumeName,
@@ -177,10 +219,14 @@ func getKanikoJobObject(
 	kanikoVolumes := []corev1.Volume{
 		{
 			Name:         gitRepoVolumeName,
-			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: build.Pod.WorkspaceSizeLimit}},
 		},
 	}
-	prepareConfigCommand := `git clone --no-checkout %[1]s /%[2]s && cd /%[2]s &&   git fetch --all &&  git checkout %[3]s `
+	prepareConfigCommand := `git clone --no-checkout "%[1]s" /%[2]s && cd /%[2]s &&   git fetch --all &&  git checkout %[3]s `
+	prepareConfigEnvVars := []corev1.EnvVar{}
+	if len(createJobConfig.GitCredentialsSecretName) != 0 {
+		prepareConfigEnvVars = append(prepareConfigEnvVars, getSecretEnvVar(gitTokenEnvName, createJobConfig.GitCredentialsSecretName, gitCredentialsSecretKey))
+	}
 	kanikoEnvVars := []corev1.EnvVar{}
 	kanikoVolumeMounts := []corev1.VolumeMount{
 		{
@@ -189,7 +235,7 @@ func getKanikoJobObject(
 		},
 	}
 	if len(createJobConfig.DockerFileConfigName) != 0 {
-		prepareConfigCommand = prepareConfigCommand + fmt.Sprintf(` && cp /%s/Dockerfile /%s/Dockerfile --force`, dockerfileConfigDirectoryName, kanikoWorkspaceName)
+		prepareConfigCommand = prepareConfigCommand + fmt.Sprintf(` && mkdir -p '%s' && cp /%s/Dockerfile '%s' --force`, path.Dir(dockerfile), dockerfileConfigDirectoryName, dockerfile)
 		prepareConfigVolumeMounts = append(prepareConfigVolumeMounts, corev1.VolumeMount{
 			Name:      dockerfileConfigDirectoryName,
 			MountPath: fmt.Sprintf("/%s", dockerfileConfigDirectoryName),
@@ -238,43 +284,140 @@ func getKanikoJobObject(
 			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
 		})
 	}
-	buildArgs, err := getKanikoBuildArgs(params)
+	// Git trusts a single bundle, so the CA bundle is added to a copy of the bundle of the image. Kaniko
+	// reads every file in /kaniko/ssl/certs, so there the CA bundle is mounted next to its own bundle.
+	network, err := GetNetworkSettings(params)
+	if err != nil {
+		return batchv1.Job{}, err
+	}
+	prepareConfigEnvVars = append(prepareConfigEnvVars, netconfig.GetProxyEnv(network)...)
+	kanikoEnvVars = append(kanikoEnvVars, netconfig.GetProxyEnv(network)...)
+	if network.CABundleConfigMap != "" {
+		kanikoVolumes = append(kanikoVolumes, netconfig.GetCABundleVolume(network))
+		prepareConfigCommand = fmt.Sprintf(`cat /etc/ssl/certs/ca-certificates.crt %s > %s && `, path.Join(netconfig.CABundleMountPath, netconfig.CABundleFileName), gitCABundleFile) + prepareConfigCommand
+		prepareConfigEnvVars = append(prepareConfigEnvVars, corev1.EnvVar{
+			Name:  "GIT_SSL_CAINFO",
+			Value: gitCABundleFile,
+		})
+		prepareConfigVolumeMounts = append(prepareConfigVolumeMounts, corev1.VolumeMount{
+			Name:      netconfig.CABundleVolumeName,
+			MountPath: netconfig.CABundleMountPath,
+			ReadOnly:  true,
+		})
+		kanikoVolumeMounts = append(kanikoVolumeMounts, corev1.VolumeMount{
+			Name:      netconfig.CABundleVolumeName,
+			MountPath: kanikoCABundleFile,
+			SubPath:   netconfig.CABundleFileName,
+			ReadOnly:  true,
+		})
+	}
+	registryFlags := getKanikoRegistryFlags(network)
+	// The warmer only needs the Dockerfile and the registry credentials, so it is set up before the
+	// build secrets are added to the kaniko container.
+	initContainers := []corev1.Container{}
+	if build.Cache.WarmBaseImages {
+		kanikoVolumes = append(kanikoVolumes, getKanikoCacheVolume(build.Cache))
+		kanikoVolumeMounts = append(kanikoVolumeMounts, corev1.VolumeMount{
+			Name:      kanikoCacheVolumeName,
+			MountPath: kanikoCacheDirectory,
+		})
+		initContainers = append(initContainers, corev1.Container{
+			Name:  "cache-warmer",
+			Image: getBuildImage(build.Images.Warmer, constants.DefaultWarmerImage),
+			// The build args are passed for base images that are selected with an ARG.
+			Args: append(append([]string{
+				fmt.Sprintf("--cache-dir=%s", kanikoCacheDirectory),
+				fmt.Sprintf("--dockerfile=%s", dockerfile),
+			}, getKanikoBuildArgs(build, params)...), registryFlags...),
+			Env:                      append([]corev1.EnvVar{}, kanikoEnvVars...),
+			VolumeMounts:             append([]corev1.VolumeMount{}, kanikoVolumeMounts...),
+			Resources:                build.Pod.Resources,
+			TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+		})
+	}
+	// RUN commands of kaniko see the files of the kaniko container, so they read the secrets from
+	// /run/secrets/<key>. Kaniko ignores RUN --mount, and mounted paths are not added to the image layers.
+	if len(createJobConfig.BuildSecretsName) != 0 {
+		kanikoVolumes = append(kanikoVolumes, corev1.Volume{
+			Name: buildSecretsVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: createJobConfig.BuildSecretsName,
+				},
+			},
+		})
+		kanikoVolumeMounts = append(kanikoVolumeMounts, corev1.VolumeMount{
+			Name:      buildSecretsVolumeName,
+			MountPath: constants.BuildSecretsMountPath,
+			ReadOnly:  true,
+		})
+	}
+	buildArgs := getKanikoBuildArgs(build, params)
+	cacheArgs, err := getKanikoCacheArgs(build.Cache, artifactsRepoUrl)
 	if err != nil {
 		return batchv1.Job{}, err
 	}
+	buildArgs = append(buildArgs, cacheArgs...)
+	buildArgs = append(buildArgs, registryFlags...)
+	kanikoFlags, err := getKanikoFlags(build.KanikoFlags)
+	if err != nil {
+		return batchv1.Job{}, err
+	}
+	buildArgs = append(buildArgs, kanikoFlags...)
+	if build.Target != "" {
+		buildArgs = append(buildArgs, fmt.Sprintf("--target=%s", build.Target))
+	}
+	// Without a value kaniko reads a build arg from the environment, so the values stay in the Secret.
+	for _, name := range createJobConfig.BuildArgNames {
+		buildArgs = append(buildArgs, fmt.Sprintf("--build-arg=%s", name))
+		kanikoEnvVars = append(kanikoEnvVars, getSecretEnvVar(name, createJobConfig.BuildSecretsName, name))
+	}
 	podSpec := corev1.PodSpec{
 		InitContainers: []corev1.Container{
 			{
-				Name:  "prepare-config",
-				Image: "alpine/git",
+				Name:  PrepareConfigContainerName,
+				Image: getBuildImage(build.Images.Git, constants.DefaultGitImage),
 				Command: []string{
 					"/bin/sh",
 					"-c",
 					fmt.Sprintf(prepareConfigCommand, gitUrl, kanikoWorkspaceName, params.CommitId),
 				},
-				VolumeMounts: prepareConfigVolumeMounts,
+				Env:                      prepareConfigEnvVars,
+				VolumeMounts:             prepareConfigVolumeMounts,
+				Resources:                build.Pod.Resources,
+				TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
 			},
 		},
 		Containers: []corev1.Container{
 			{
-				Name:            "kaniko",
-				Image:           "gcr.io/kaniko-project/executor:latest",
-				ImagePullPolicy: corev1.PullAlways,
+				Name:  "kaniko",
+				Image: getBuildImage(build.Images.Kaniko, constants.DefaultKanikoImage),
 				Args: append(
 					[]string{
-						fmt.Sprintf("--context=dir:///%s", kanikoWorkspaceName),
-						fmt.Sprintf("--dockerfile=/%s/Dockerfile", kanikoWorkspaceName),
+						fmt.Sprintf("--context=dir://%s", contextDirectory),
+						fmt.Sprintf("--dockerfile=%s", dockerfile),
 						fmt.Sprintf("--destination=%s", artifactsRepoUrl),
+						"--digest-file=/dev/termination-log",
 					}, buildArgs...),
-				Env:          kanikoEnvVars,
-				VolumeMounts: kanikoVolumeMounts,
+				Env:                      kanikoEnvVars,
+				VolumeMounts:             kanikoVolumeMounts,
+				Resources:                build.Pod.Resources,
+				TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
 			},
 		},
 		RestartPolicy:      corev1.RestartPolicyNever,
 		Volumes:            kanikoVolumes,
 		ServiceAccountName: "humalect-sa",
+		NodeSelector:       build.Pod.NodeSelector,
+		Tolerations:        build.Pod.Tolerations,
+		Affinity:           build.Pod.Affinity,
+		PriorityClassName:  build.Pod.PriorityClassName,
 	}
+	podSpec.InitContainers = append(podSpec.InitContainers, initContainers...)
 	backoffLimit := int32(0)
+	if build.Pod.BackoffLimit != nil {
+		backoffLimit = *build.Pod.BackoffLimit
+	}
 	jobSpec := batchv1.JobSpec{
 		Template: corev1.PodTemplateSpec{
 			ObjectMeta: metav1.ObjectMeta{
@@ -289,7 +432,8 @@ func getKanikoJobObject(
 			},
 			Spec: podSpec,
 		},
-		BackoffLimit: &backoffLimit,
+		BackoffLimit:          &backoffLimit,
+		ActiveDeadlineSeconds: build.Pod.TimeoutSeconds,
 	}
 	job := batchv1.Job{
 		TypeMeta: metav1.TypeMeta{
@@ -320,31 +464,215 @@ func getKanikoJobObject(
 	return job, nil
 }
 
+// buildPathPattern keeps the paths safe to use in the shell command of the prepare-config container.
+var buildPathPattern = regexp.MustCompile(`^[A-Za-z0-9._/-]*$`)
+
+// GetBuildSpec parses the build settings the DeploymentSet resolved with the build defaults.
+func GetBuildSpec(params constants.ParamsConfig) (constants.BuildSpec, error) {
+	var build constants.BuildSpec
+	if params.Build == "" {
+		return build, nil
+	}
+	if err := json.Unmarshal([]byte(params.Build), &build); err != nil {
+		return build, utils.NewDeploymentError(constants.ErrorCategoryBuildFailed, fmt.Errorf("invalid build settings: %w", err))
+	}
+	return build, nil
+}
+
+// getBuildPaths returns the build context and the Dockerfile in the workspace. Both paths of the build are
+// relative to the root of the repository and can not point outside of it.
+func getBuildPaths(build constants.BuildSpec) (string, string, error) {
+	workspace := fmt.Sprintf("/%s", kanikoWorkspaceName)
+	paths := []string{}
+	for _, buildPath := range []string{build.ContextPath, build.DockerfilePath} {
+		cleanPath := path.Clean("/" + buildPath)
+		if !buildPathPattern.MatchString(buildPath) || strings.Contains(buildPath, "..") {
+			return "", "", utils.NewDeploymentError(constants.ErrorCategoryBuildFailed, fmt.Errorf("invalid build path %q", buildPath))
+		}
+		paths = append(paths, path.Join(workspace, cleanPath))
+	}
+	contextDirectory, dockerfile := paths[0], paths[1]
+	if build.DockerfilePath == "" {
+		dockerfile = path.Join(contextDirectory, "Dockerfile")
+	}
+	return contextDirectory, dockerfile, nil
+}
+
+// getBuildImage returns the configured image. Without a pull policy Kubernetes pulls images tagged
+// latest every time and keeps images pinned by tag or digest.
+func getBuildImage(image string, defaultImage string) string {
+	if image == "" {
+		return defaultImage
+	}
+	return image
+}
+
+// managedKanikoFlags are set by the agent, changing them would build or push something else.
+var managedKanikoFlags = []string{"--context", "--dockerfile", "--destination", "--digest-file", "--build-arg", "--target", "--cache", "--cache-repo", "--cache-ttl", "--cache-dir"}
+
+// getKanikoFlags checks the extra flags of the build, the flags the agent sets itself are rejected.
+func getKanikoFlags(flags []string) ([]string, error) {
+	for _, flag := range flags {
+		name := strings.SplitN(flag, "=", 2)[0]
+		if !strings.HasPrefix(name, "--") {
+			return nil, utils.NewDeploymentError(constants.ErrorCategoryBuildFailed, fmt.Errorf("invalid kaniko flag %q", flag))
+		}
+		for _, managedFlag := range managedKanikoFlags {
+			if name == managedFlag {
+				return nil, utils.NewDeploymentError(constants.ErrorCategoryBuildFailed, fmt.Errorf("kaniko flag %s is set by the build", name))
+			}
+		}
+	}
+	return flags, nil
+}
+
+// getKanikoCacheArgs returns the kaniko flags of the layer cache. Cached layers go to the cache
+// repository next to the image unless the cache has its own repository.
+func getKanikoCacheArgs(cache constants.BuildCacheSpec, imageReference string) ([]string, error) {
+	args := []string{}
+	if cache.WarmBaseImages {
+		args = append(args, fmt.Sprintf("--cache-dir=%s", kanikoCacheDirectory))
+	}
+	if !cache.Enabled {
+		return args, nil
+	}
+	repo := cache.Repo
+	if repo == "" {
+		repo = getImageRepository(imageReference) + "/cache"
+	}
+	args = append(args, "--cache=true", fmt.Sprintf("--cache-repo=%s", repo))
+	if cache.TTL != "" {
+		if _, err := time.ParseDuration(cache.TTL); err != nil {
+			return nil, utils.NewDeploymentError(constants.ErrorCategoryBuildFailed, fmt.Errorf("invalid cache ttl %q: %v", cache.TTL, err))
+		}
+		args = append(args, fmt.Sprintf("--cache-ttl=%s", cache.TTL))
+	}
+	return args, nil
+}
+
+// getImageRepository strips the digest and the tag from an image reference, a registry port is kept.
+func getImageRepository(imageReference string) string {
+	imageReference = strings.SplitN(imageReference, "@", 2)[0]
+	tagIndex := strings.LastIndex(imageReference, ":")
+	if tagIndex > strings.LastIndex(imageReference, "/") {
+		return imageReference[:tagIndex]
+	}
+	return imageReference
+}
+
+// getKanikoCacheVolume keeps the warmed base images in the claim of the cache, or only for this build.
+func getKanikoCacheVolume(cache constants.BuildCacheSpec) corev1.Volume {
+	if cache.BaseImageCacheClaim != "" {
+		return corev1.Volume{
+			Name: kanikoCacheVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: cache.BaseImageCacheClaim},
+			},
+		}
+	}
+	return corev1.Volume{
+		Name:         kanikoCacheVolumeName,
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	}
+}
+
+// getKanikoBuildArgs returns the plain build args of the build together with the predefined ones, sorted
+// so the Job spec does not change between runs.
+func getKanikoBuildArgs(build constants.BuildSpec, params constants.ParamsConfig) []string {
+	args := map[string]string{
+		constants.BuildArgCommitSha:  params.CommitId,
+		constants.BuildArgPipelineId: params.PipelineId,
+	}
+	for name, value := range build.Args {
+		args[name] = value
+	}
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	buildArgs := []string{}
+	for _, name := range names {
+		buildArgs = append(buildArgs, fmt.Sprintf("--build-arg=%s=%s", name, args[name]))
+	}
+	return buildArgs
+}
+
 func createKanikoConfigResources(clientset *kubernetes.Clientset, params constants.ParamsConfig) (CreateJobConfig, error) {
+	createJobConfig := CreateJobConfig{}
 	cloudProviderSecretName, err := createArtifactsSecret(clientset, params)
 	if err != nil {
-		log.Fatalf("Error Creating Artifacts Secret: %v", err)
-		// return CreateJobConfig{}, err
+		logger.Log().Errorw("Error Creating Artifacts Secret", "error", err)
+		return createJobConfig, err
+	}
+	createJobConfig.CloudProviderSecretName = cloudProviderSecretName
+
+	createJobConfig.DockerFileConfigName, err = getDockerFileConfig(clientset, params)
+	if err != nil {
+		logger.Log().Errorw("Error creating Dockerfile Config", "error", err)
+		return createJobConfig, err
+	}
+
+	if params.SourceCodeToken != "" {
+		createJobConfig.GitCredentialsSecretName, err = k8s.CreateOpaqueSecret(map[string]string{gitCredentialsSecretKey: params.SourceCodeToken}, params, clientset, "humalect", "git")
+		if err != nil {
+			logger.Log().Errorw("Error creating git credentials Secret", "error", err)
+			return createJobConfig, utils.NewDeploymentError(constants.ErrorCategoryApplyFailed, err)
+		}
 	}
 
-	dockerFileConfigName, err := getDockerFileConfig(clientset, params)
+	createJobConfig.BuildSecretsName, createJobConfig.BuildArgNames, err = createBuildSecretsSecret(clientset, params)
 	if err != nil {
-		log.Fatalf("Error creating Dockerfile Config: %v", err)
-		return CreateJobConfig{}, err
+		logger.Log().Errorw("Error creating build secrets Secret", "error", err)
+		return createJobConfig, err
 	}
-	return CreateJobConfig{CloudProviderSecretName: cloudProviderSecretName, DockerFileConfigName: dockerFileConfigName}, nil
+	return createJobConfig, nil
 }
 
-func getKanikoBuildArgs(params constants.ParamsConfig) ([]string, error) {
+// createBuildSecretsSecret stores the build secrets in a Secret that is mounted into the kaniko container.
+// The returned build args are the keys of the build secrets listed in BuildArgsFromSecrets.
+func createBuildSecretsSecret(clientset *kubernetes.Clientset, params constants.ParamsConfig) (string, []string, error) {
 	secretData, err := FetchBuildSecrets(params)
 	if err != nil {
-		return []string{}, err
+		return "", nil, utils.NewDeploymentError(constants.ErrorCategorySecretFetch, err)
 	}
-	secretArgs := []string{}
+	buildSecrets := map[string]string{}
 	for key, value := range secretData {
 		if value != "" {
-			secretArgs = append(secretArgs, fmt.Sprintf("--build-arg=%s=%s", key, value))
+			buildSecrets[key] = value
+		}
+	}
+	if len(buildSecrets) == 0 {
+		return "", nil, nil
+	}
+	build, err := GetBuildSpec(params)
+	if err != nil {
+		return "", nil, err
+	}
+	names := []string{}
+	for _, name := range build.BuildArgsFromSecrets {
+		if _, ok := buildSecrets[name]; !ok {
+			logger.Log().Warnw("Build arg is not a key of the build secrets", "buildArg", name)
+			continue
 		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	secretName, err := k8s.CreateOpaqueSecret(buildSecrets, params, clientset, "humalect", "buildsecrets")
+	if err != nil {
+		return "", nil, utils.NewDeploymentError(constants.ErrorCategoryApplyFailed, err)
+	}
+	return secretName, names, nil
+}
+
+func getSecretEnvVar(name string, secretName string, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  key,
+			},
+		},
 	}
-	return secretArgs, err
 }
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Humalect/humalect-core/agent/constants"
+)
+
+func TestGetBuildPaths(t *testing.T) {
+	tests := []struct {
+		name           string
+		build          constants.BuildSpec
+		wantContext    string
+		wantDockerfile string
+		wantErr        bool
+	}{
+		{name: "root of the repository", build: constants.BuildSpec{}, wantContext: "/workspace", wantDockerfile: "/workspace/Dockerfile"},
+		{name: "context subdirectory", build: constants.BuildSpec{ContextPath: "services/api"}, wantContext: "/workspace/services/api", wantDockerfile: "/workspace/services/api/Dockerfile"},
+		{name: "context subdirectory with dockerfile", build: constants.BuildSpec{ContextPath: "services/api", DockerfilePath: "docker/api.Dockerfile"}, wantContext: "/workspace/services/api", wantDockerfile: "/workspace/docker/api.Dockerfile"},
+		{name: "trailing slash", build: constants.BuildSpec{ContextPath: "services/api/"}, wantContext: "/workspace/services/api", wantDockerfile: "/workspace/services/api/Dockerfile"},
+		{name: "context outside of the repository", build: constants.BuildSpec{ContextPath: "../"}, wantErr: true},
+		{name: "dockerfile outside of the repository", build: constants.BuildSpec{DockerfilePath: "services/../../Dockerfile"}, wantErr: true},
+		{name: "shell characters", build: constants.BuildSpec{ContextPath: "api; rm -rf /"}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			contextDirectory, dockerfile, err := getBuildPaths(test.build)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("getBuildPaths() = %q, %q, want an error", contextDirectory, dockerfile)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getBuildPaths() error = %v", err)
+			}
+			if contextDirectory != test.wantContext || dockerfile != test.wantDockerfile {
+				t.Errorf("getBuildPaths() = %q, %q, want %q, %q", contextDirectory, dockerfile, test.wantContext, test.wantDockerfile)
+			}
+		})
+	}
+}
+
+func TestGetImageRepository(t *testing.T) {
+	tests := map[string]string{
+		"img":                             "img",
+		"img:1.0":                         "img",
+		"registry.example.com/team/img:1": "registry.example.com/team/img",
+		"host:5000/img":                   "host:5000/img",
+		"host:5000/img:1.0":               "host:5000/img",
+		"img@sha256:4bf92f3577b34da6":     "img",
+		"host:5000/img:1.0@sha256:4bf92f": "host:5000/img",
+	}
+	for imageReference, want := range tests {
+		if got := getImageRepository(imageReference); got != want {
+			t.Errorf("getImageRepository(%q) = %q, want %q", imageReference, got, want)
+		}
+	}
+}
+
+func TestGetKanikoCacheArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		cache   constants.BuildCacheSpec
+		image   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "disabled", cache: constants.BuildCacheSpec{}, image: "host:5000/img:1.0", want: []string{}},
+		{name: "cache next to the image", cache: constants.BuildCacheSpec{Enabled: true}, image: "host:5000/img:1.0", want: []string{"--cache=true", "--cache-repo=host:5000/img/cache"}},
+		{name: "cache of a digest image", cache: constants.BuildCacheSpec{Enabled: true}, image: "img@sha256:4bf92f", want: []string{"--cache=true", "--cache-repo=img/cache"}},
+		{name: "own cache repository", cache: constants.BuildCacheSpec{Enabled: true, Repo: "cache.example.com/img", TTL: "24h"}, image: "img:1.0", want: []string{"--cache=true", "--cache-repo=cache.example.com/img", "--cache-ttl=24h"}},
+		{name: "warmed base images", cache: constants.BuildCacheSpec{WarmBaseImages: true}, image: "img:1.0", want: []string{"--cache-dir=/cache"}},
+		{name: "invalid ttl", cache: constants.BuildCacheSpec{Enabled: true, TTL: "a day"}, image: "img:1.0", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := getKanikoCacheArgs(test.cache, test.image)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("getKanikoCacheArgs() = %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getKanikoCacheArgs() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("getKanikoCacheArgs() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGetKanikoFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   []string
+		wantErr bool
+	}{
+		{name: "no flags", flags: nil},
+		{name: "extra flags", flags: []string{"--snapshot-mode=redo", "--use-new-run", "--compressed-caching=false"}},
+		{name: "managed destination", flags: []string{"--destination=x"}, wantErr: true},
+		{name: "managed cache repository", flags: []string{"--snapshot-mode=redo", "--cache-repo=x"}, wantErr: true},
+		{name: "managed flag without value", flags: []string{"--cache"}, wantErr: true},
+		{name: "not a flag", flags: []string{"destination=x"}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := getKanikoFlags(test.flags)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("getKanikoFlags() = %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getKanikoFlags() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, test.flags) {
+				t.Errorf("getKanikoFlags() = %v, want %v", got, test.flags)
+			}
+		})
+	}
+}
@@ -2,19 +2,20 @@ package dockerhub
 
 import (
 	"fmt"
-	"log"
 	"strings"
 
 	"github.com/Humalect/humalect-core/agent/constants"
+	"github.com/Humalect/humalect-core/agent/logger"
 	"github.com/Humalect/humalect-core/agent/services/k8s"
+	"github.com/Humalect/humalect-core/agent/utils"
 	"k8s.io/client-go/kubernetes"
 )
 
 func CreateSecret(params constants.ParamsConfig, clientSet *kubernetes.Clientset) (string, error) {
 	secretKey, err := FetchDockerHubSecretKey(params)
 	if err != nil {
-		log.Fatalf("Error getting dockerhub secret: %v", err)
-		return "", err
+		logger.Log().Errorw("Error getting dockerhub secret", "error", err)
+		return "", utils.NewDeploymentError(constants.ErrorCategorySecretFetch, err)
 	}
 	secretData := strings.Join(strings.Fields(fmt.Sprintf(`{
 			"auths": {
@@ -24,7 +25,8 @@ func CreateSecret(params constants.ParamsConfig, clientSet *kubernetes.Clientset
 			}
 		}`, secretKey)), "")
 
-	return k8s.CreateSecret(map[string]string{
+	secretName, err := k8s.CreateSecret(map[string]string{
 		".dockerconfigjson": secretData,
 	}, params, clientSet, "humalect")
+	return secretName, utils.NewDeploymentError(constants.ErrorCategoryApplyFailed, err)
 }
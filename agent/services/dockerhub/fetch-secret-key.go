@@ -3,7 +3,6 @@ package dockerhub
 import (
 	"encoding/json"
 	"errors"
-	"log"
 
 	"github.com/Humalect/humalect-core/agent/constants"
 	"github.com/Humalect/humalect-core/agent/services/aws"
@@ -29,7 +28,6 @@ func FetchDockerHubSecretKey(params constants.ParamsConfig) (string, error) {
 		_ = json.Unmarshal([]byte(params.AzureVaultCredentials), &azureVaultCredentials)
 		secretData, err := azure.GetSecretValue(azureVaultCredentials.Token, azureVaultCredentials.Name, dockerHubCreds.SecretName)
 		if err != nil {
-			log.Fatalf("Error getting dockerhub secret: %v", err)
 			return "", err
 		}
 		return secretData[constants.RegistryIdDockerhub], nil
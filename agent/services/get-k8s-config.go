@@ -1,11 +1,12 @@
 package services
 
 import (
-	"log"
 	"os"
 
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/Humalect/humalect-core/agent/logger"
 )
 
 func GetK8sConfig() *rest.Config {
@@ -25,7 +26,7 @@ func GetK8sConfig() *rest.Config {
 
 		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
 		if err != nil {
-			log.Fatalf("Error building kubeconfig: %v", err)
+			logger.Log().Fatalw("Error building kubeconfig", "error", err)
 			panic(err)
 		}
 	}
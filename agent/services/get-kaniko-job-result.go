@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+type KanikoJobResult struct {
+	PodName     string
+	ImageDigest string
+	// Container is the name of the container that failed.
+	Container  string
+	Reason     string
+	Message    string
+	LogExcerpt string
+}
+
+// GetKanikoJobResult reads the outcome of the kaniko pod. Kaniko writes the image digest to the termination
+// log, containers that fail fall back to the tail of their logs as termination message.
+func GetKanikoJobResult(namespace, jobName string) (KanikoJobResult, error) {
+	result := KanikoJobResult{}
+	clientset, err := kubernetes.NewForConfig(GetK8sConfig())
+	if err != nil {
+		return result, err
+	}
+	pods, err := clientset.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: fmt.Sprintf("job-name=%s", jobName)})
+	if err != nil {
+		return result, err
+	}
+	if len(pods.Items) == 0 {
+		return result, fmt.Errorf("no pod found for job %s", jobName)
+	}
+	pod := pods.Items[0]
+	for _, item := range pods.Items[1:] {
+		if item.CreationTimestamp.After(pod.CreationTimestamp.Time) {
+			pod = item
+		}
+	}
+	result.PodName = pod.GetName()
+
+	for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		terminated := status.State.Terminated
+		if terminated == nil {
+			continue
+		}
+		if terminated.ExitCode != 0 {
+			result.Container = status.Name
+			result.Reason = terminated.Reason
+			result.Message = fmt.Sprintf("container %s exited with code %d", status.Name, terminated.ExitCode)
+			result.LogExcerpt = strings.TrimSpace(terminated.Message)
+			return result, nil
+		}
+		if status.Name == "kaniko" {
+			result.ImageDigest = strings.TrimSpace(terminated.Message)
+		}
+	}
+	return result, nil
+}
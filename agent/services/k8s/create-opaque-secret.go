@@ -0,0 +1,46 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/Humalect/humalect-core/agent/constants"
+	"k8s.io/client-go/kubernetes"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CreateOpaqueSecret creates a Secret that is only read by the kaniko job, so the values are not part of
+// the Job spec. The kind tells the Secrets of a deployment apart.
+func CreateOpaqueSecret(secretData map[string]string, params constants.ParamsConfig, clientset *kubernetes.Clientset, namespace string, kind string) (string, error) {
+	name := fmt.Sprintf("%s-%s-%s-%s",
+		params.ManagedBy[:int(math.Min(float64(len(params.ManagedBy)), float64(10)))],
+		kind,
+		params.CommitId[:int(math.Min(float64(len(params.CommitId)), float64(5)))],
+		params.DeploymentId[:int(math.Min(float64(len(params.DeploymentId)), float64(7)))])
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app":          name,
+				"deploymentId": params.DeploymentId,
+				"managedBy":    params.ManagedBy,
+				"commitId":     params.CommitId,
+				"pipelineId":   params.PipelineId,
+				"partOf":       "humalect-core",
+				"resourceType": kind + "-secret",
+			},
+		},
+		Type:       corev1.SecretTypeOpaque,
+		StringData: secretData,
+	}
+
+	createdSecret, err := clientset.CoreV1().Secrets(namespace).Create(context.Background(), secret, metav1.CreateOptions{})
+	if err != nil {
+		return "", err
+	}
+	return createdSecret.Name, nil
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+
+	"github.com/Humalect/humalect-core/agent/constants"
+	"github.com/Humalect/humalect-core/agent/utils"
+	"github.com/Humalect/humalect-core/pkg/netconfig"
+)
+
+// GetNetworkSettings parses the CA bundle, proxy and insecure registries the DeploymentSet passed on.
+func GetNetworkSettings(params constants.ParamsConfig) (netconfig.Settings, error) {
+	var network netconfig.Settings
+	if params.Network == "" {
+		return network, nil
+	}
+	if err := json.Unmarshal([]byte(params.Network), &network); err != nil {
+		return network, utils.NewDeploymentError(constants.ErrorCategoryBuildFailed, fmt.Errorf("invalid network settings: %w", err))
+	}
+	return network, nil
+}
+
+// SetupNetwork reads the network settings and trusts their CA bundle. It runs before any client is created.
+func SetupNetwork(params constants.ParamsConfig) error {
+	network, err := GetNetworkSettings(params)
+	if err != nil {
+		return err
+	}
+	if err := SetupCABundle(network); err != nil {
+		return utils.NewDeploymentError(constants.ErrorCategoryBuildFailed, fmt.Errorf("failed to set up the CA bundle: %w", err))
+	}
+	return nil
+}
+
+// SetupCABundle adds the CA bundle mounted by the controller to the CAs of http.DefaultTransport, which
+// the AWS, Azure and webhook clients use. The public CAs stay trusted. Clients of the aws-sdk-go-v2 build
+// their own transport and are passed GetHTTPClient.
+func SetupCABundle(network netconfig.Settings) error {
+	if network.CABundleConfigMap == "" {
+		return nil
+	}
+	bundleFile := path.Join(netconfig.CABundleMountPath, netconfig.CABundleFileName)
+	bundle, err := os.ReadFile(bundleFile)
+	if err != nil {
+		return err
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(bundle) {
+		return fmt.Errorf("no certificates found in %s", bundleFile)
+	}
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return errors.New("the default HTTP transport can not be configured")
+	}
+	tlsConfig := &tls.Config{}
+	if transport.TLSClientConfig != nil {
+		tlsConfig = transport.TLSClientConfig.Clone()
+	}
+	tlsConfig.RootCAs = pool
+	transport.TLSClientConfig = tlsConfig
+	return nil
+}
+
+// GetHTTPClient returns a client on http.DefaultTransport, so it trusts the CA bundle and uses the proxy.
+func GetHTTPClient() *http.Client {
+	return &http.Client{Transport: http.DefaultTransport}
+}
+
+// getKanikoRegistryFlags returns the flags of the registries that are reached without TLS or without
+// verifying their certificate.
+func getKanikoRegistryFlags(network netconfig.Settings) []string {
+	flags := []string{}
+	for _, registry := range network.InsecureRegistries {
+		flags = append(flags, fmt.Sprintf("--insecure-registry=%s", registry))
+	}
+	for _, registry := range network.SkipTLSVerifyRegistries {
+		flags = append(flags, fmt.Sprintf("--skip-tls-verify-registry=%s", registry))
+	}
+	return flags
+}
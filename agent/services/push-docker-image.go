@@ -17,6 +17,8 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/jsonmessage"
+
+	"github.com/Humalect/humalect-core/agent/logger"
 )
 
 func PushDockerImage(cloudProvider string,
@@ -32,9 +34,9 @@ func PushDockerImage(cloudProvider string,
 	if cloudProvider == "aws" {
 		ctx := context.Background()
 
-		cfg, err := config.LoadDefaultConfig(ctx)
+		cfg, err := config.LoadDefaultConfig(ctx, config.WithHTTPClient(GetHTTPClient()))
 		if err != nil {
-			fmt.Println(err)
+			logger.Log().Errorw("Error loading AWS config", "error", err)
 			return err
 		}
 
@@ -42,7 +44,7 @@ func PushDockerImage(cloudProvider string,
 		input := &ecr.GetAuthorizationTokenInput{}
 		resp, err := client.GetAuthorizationToken(ctx, input)
 		if err != nil {
-			fmt.Println(err)
+			logger.Log().Errorw("Error getting ECR authorization token", "error", err)
 			return err
 		}
 
@@ -53,7 +55,7 @@ func PushDockerImage(cloudProvider string,
 		authData := resp.AuthorizationData[0]
 		decodedToken, err := base64.StdEncoding.DecodeString(*authData.AuthorizationToken)
 		if err != nil {
-			fmt.Println(err)
+			logger.Log().Errorw("Error decoding ECR authorization token", "error", err)
 			return err
 		}
 
@@ -78,7 +80,7 @@ func PushDockerImage(cloudProvider string,
 
 		req, err := http.NewRequest("POST", url, strings.NewReader("{}"))
 		if err != nil {
-			fmt.Println(err)
+			logger.Log().Errorw("Error creating ACR credentials request", "error", err)
 			return err
 		}
 
@@ -87,14 +89,14 @@ func PushDockerImage(cloudProvider string,
 
 		resp, err := client.Do(req)
 		if err != nil {
-			fmt.Println(err)
+			logger.Log().Errorw("Error requesting ACR credentials", "error", err)
 			return err
 		}
 		defer resp.Body.Close()
 
 		body, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
-			fmt.Println(err)
+			logger.Log().Errorw("Error reading ACR credentials", "error", err)
 			return err
 		}
 
@@ -107,7 +109,7 @@ func PushDockerImage(cloudProvider string,
 
 		err = json.Unmarshal(body, &data)
 		if err != nil {
-			fmt.Println(err)
+			logger.Log().Errorw("Error parsing ACR credentials", "error", err)
 			return err
 		}
 
@@ -122,7 +124,7 @@ func PushDockerImage(cloudProvider string,
 		}
 
 	} else {
-		fmt.Println("Error: Invalid cloudProvider")
+		logger.Log().Errorw("Invalid cloudProvider", "cloudProvider", cloudProvider)
 		return errors.New("Error: Invalid cloudProvider")
 
 	}
@@ -131,7 +133,7 @@ func PushDockerImage(cloudProvider string,
 	pushDockerImageContext := context.Background()
 	pushDockerImageClient, err := client.NewClientWithOpts(client.FromEnv)
 	if err != nil {
-		fmt.Println(err)
+		logger.Log().Errorw("Error creating docker client", "error", err)
 		return err
 	}
 	pushDockerImageClient.NegotiateAPIVersion(pushDockerImageContext)
@@ -142,19 +144,18 @@ func PushDockerImage(cloudProvider string,
 	}
 	response, err := pushDockerImageClient.ImagePush(pushDockerImageContext, artifactsRepoLink, options)
 	if err != nil {
-		fmt.Println(err)
+		logger.Log().Errorw("Error pushing docker image", "error", err)
 		return err
 	}
 	defer response.Close()
 
 	err = handleProgressMessages(response)
 	if err != nil {
-		fmt.Println(err)
+		logger.Log().Errorw("Error pushing docker image", "error", err)
 		return err
 	}
 
-	fmt.Println("<======== Finished Pushing Docker file =======>")
-	fmt.Println("Pushed image repo link:", artifactsRepoLink)
+	logger.Log().Infow("Pushed docker image", "image", artifactsRepoLink)
 
 	return nil
 }
@@ -166,12 +167,12 @@ func handleProgressMessages(response io.ReadCloser) error {
 
 		err := json.Unmarshal(scanner.Bytes(), &event)
 		if err != nil {
-			fmt.Println(err)
+			logger.Log().Errorw("Error parsing push progress", "error", err)
 			return err
 		}
 
 		if event.Status != "" {
-			fmt.Println(event.Status)
+			logger.Log().Debugw("Push progress", "status", event.Status)
 		}
 
 	}
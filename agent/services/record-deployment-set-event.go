@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Humalect/humalect-core/agent/constants"
+	"github.com/Humalect/humalect-core/agent/logger"
+	"github.com/Humalect/humalect-core/pkg/redact"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// RecordDeploymentSetEvent emits a Kubernetes Event on the DeploymentSet that started this deployment so
+// the agent steps show up in kubectl describe. Failures are only logged, events are best effort.
+func RecordDeploymentSetEvent(params constants.ParamsConfig, eventType string, reason string, messageFmt string, args ...interface{}) {
+	if params.DeploymentSetName == "" {
+		return
+	}
+	config := GetK8sConfig()
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		logger.Log().Errorw("Error creating client to record event", "error", err)
+		return
+	}
+	deploymentSet, err := dynamicClient.Resource(deploymentSetGVR).Namespace(params.DeploymentSetNamespace).Get(context.TODO(), params.DeploymentSetName, metav1.GetOptions{})
+	if err != nil {
+		logger.Log().Errorw("Error getting DeploymentSet to record event", "error", err)
+		return
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		logger.Log().Errorw("Error creating client to record event", "error", err)
+		return
+	}
+
+	now := metav1.NewTime(time.Now())
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: deploymentSet.GetName() + ".",
+			Namespace:    deploymentSet.GetNamespace(),
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion:      deploymentSet.GetAPIVersion(),
+			Kind:            deploymentSet.GetKind(),
+			Name:            deploymentSet.GetName(),
+			Namespace:       deploymentSet.GetNamespace(),
+			UID:             deploymentSet.GetUID(),
+			ResourceVersion: deploymentSet.GetResourceVersion(),
+		},
+		Reason:              reason,
+		Message:             redact.String(fmt.Sprintf(messageFmt, args...)),
+		Type:                eventType,
+		Source:              corev1.EventSource{Component: "humalect-agent"},
+		FirstTimestamp:      now,
+		LastTimestamp:       now,
+		Count:               1,
+		ReportingController: "humalect-agent",
+	}
+	if _, err := clientset.CoreV1().Events(deploymentSet.GetNamespace()).Create(context.TODO(), event, metav1.CreateOptions{}); err != nil {
+		logger.Log().Errorw("Error recording event", "error", err)
+	}
+}
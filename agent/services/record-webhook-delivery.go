@@ -0,0 +1,29 @@
+package services
+
+import (
+	"context"
+
+	"github.com/Humalect/humalect-core/pkg/webhook"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+)
+
+// RecordWebhookDelivery records the webhook as a WebhookDelivery, the controller posts it until the endpoint
+// acknowledges it so the event is not lost when the agent exits. The event is sent to the notification
+// policies as well.
+func RecordWebhookDelivery(webhookEndpoint string, request webhook.Request, idempotencyKey string, webhookData WebhookData, success bool, step string, details WebhookStepDetails) error {
+	dynamicClient, err := dynamic.NewForConfig(GetK8sConfig())
+	if err != nil {
+		return err
+	}
+	delivery, err := webhook.NewDelivery("humalect", webhookEndpoint, request, idempotencyKey, webhook.NewEvent(webhookData, success, step, details))
+	if err != nil {
+		return err
+	}
+	_, err = dynamicClient.Resource(webhook.DeliveryGVR).Namespace(delivery.GetNamespace()).Create(context.TODO(), delivery, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
@@ -1,99 +1,78 @@
 package services
 
 import (
-	"bytes"
 	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"net/http"
 
 	"github.com/Humalect/humalect-core/agent/constants"
+	"github.com/Humalect/humalect-core/agent/logger"
+	"github.com/Humalect/humalect-core/pkg/redact"
+	"github.com/Humalect/humalect-core/pkg/webhook"
+	"k8s.io/client-go/util/retry"
 )
 
-func SendWebhookRequest(webhookEndpoint string, data map[string]interface{}) (response *WebhookResponse, err error) {
-	apiURL := webhookEndpoint
-
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := http.Post(apiURL, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		return &WebhookResponse{
-			Success: true,
-			Status:  resp.StatusCode,
-			Data:    body,
-		}, nil
-	}
-
-	return &WebhookResponse{
-		Success: false,
-		Status:  resp.StatusCode,
-		Data:    body,
-	}, nil
+func SendWebhookRequest(webhookEndpoint string, request webhook.Request, idempotencyKey string) (response *webhook.Response, err error) {
+	return getDefaultWebhookClient().Send(webhookEndpoint, request, idempotencyKey)
 }
 
-func CreateSendWebhookRequest(webhookEndpoint string, data map[string]interface{}) (response *WebhookResponse, err error) {
-	response, err = SendWebhookRequest(webhookEndpoint, data)
+func CreateSendWebhookRequest(webhookEndpoint string, request webhook.Request, idempotencyKey string) (response *webhook.Response, err error) {
+	response, err = SendWebhookRequest(webhookEndpoint, request, idempotencyKey)
 	if err != nil {
-		fmt.Printf("Error Sending Webhook: %v\n", err)
+		logger.Log().Errorw("Error sending webhook", "error", err)
+		return response, err
 	}
 
 	if response.Success {
-		fmt.Printf("Sent Webhook Status: %d\n", response.Status)
-		fmt.Printf("Sent Webhook Body: %v\n", response.Data)
+		logger.Log().Infow("Sent webhook", "status", response.Status)
+		logger.Log().Debugw("Webhook response", "body", string(response.Data))
 	} else {
-		fmt.Printf("Error Response Received while Sending Webhook: %v\n", response.Data)
+		logger.Log().Errorw("Error response received while sending webhook", "status", response.Status, "body", string(response.Data))
 	}
 	return response, err
 }
 
 func SendWebhook(WebhookEndpoint string, data string, success bool, state string) {
-	if len(WebhookEndpoint) > 0 {
-		var WebhookData map[string]interface{}
-		err := json.Unmarshal([]byte(data), &WebhookData)
-		if err != nil {
-			fmt.Println("Some error occured while parsing webhook data:= ", err)
-		}
+	SendWebhookWithDetails(WebhookEndpoint, data, success, state, WebhookStepDetails{})
+}
 
-		var strStatus string
-		if success {
-			strStatus = state
-		} else {
-			strStatus = constants.DeploymentFailed
-		}
-		if _, ok := WebhookData["statusData"]; ok {
-			WebhookData["statusData"].(map[string]interface{})[state] = success
-		} else {
-			WebhookData["statusData"] = map[string]interface{}{}
-			WebhookData["statusData"].(map[string]interface{})[state] = success
-		}
-		webhookData := map[string]interface{}{
-			"type": constants.WebhookTypeDeploymentStatusUpdate,
-			"data": map[string]interface{}{
-				"queueName":  WebhookData["queueName"],
-				"statusData": WebhookData["statusData"],
-				"state":      WebhookData["state"],
-				"status":     strStatus,
-			},
-		}
-		CreateSendWebhookRequest(WebhookEndpoint, webhookData)
+// SendWebhookWithDetails sends the webhook of a step together with its error, reason and duration.
+// Without a webhook endpoint the event is still recorded for the notification policies.
+func SendWebhookWithDetails(WebhookEndpoint string, data string, success bool, state string, details WebhookStepDetails) {
+	var webhookData WebhookData
+	err := json.Unmarshal([]byte(data), &webhookData)
+	if err != nil {
+		logger.Log().Errorw("Error parsing webhook data", "error", err)
 	}
-}
+	if len(WebhookEndpoint) == 0 && webhookData.DeploymentId == "" {
+		return
+	}
+	details.Error = redact.String(details.Error)
+	details.Reason = redact.String(details.Reason)
+	details.LogExcerpt = redact.String(details.LogExcerpt)
+	if webhookData.StatusData == nil {
+		webhookData.StatusData = map[string]bool{}
+	}
+	webhookData.StatusData[state] = success
 
-type WebhookResponse struct {
-	Success bool
-	Status  int
-	Data    []byte
+	request, err := webhook.BuildRequest(webhookData, success, state, details, constants.CloudEventSourceAgent)
+	if err != nil {
+		logger.Log().Errorw("Error building webhook payload", "error", err)
+		return
+	}
+	idempotencyKey := webhook.IdempotencyKey(webhookData.DeploymentId, state)
+	err = RecordWebhookDelivery(WebhookEndpoint, request, idempotencyKey, webhookData, success, state, details)
+	if err == nil {
+		return
+	}
+	if len(WebhookEndpoint) > 0 {
+		logger.Log().Errorw("Error recording webhook delivery, sending it right away", "error", err)
+		CreateSendWebhookRequest(WebhookEndpoint, request, idempotencyKey)
+	}
+	// The controller counts the deployment metrics and notifies the policies from the recorded event, so it is
+	// still recorded, without the endpoint that was posted to already.
+	err = retry.OnError(retry.DefaultBackoff, func(error) bool { return true }, func() error {
+		return RecordWebhookDelivery("", request, idempotencyKey, webhookData, success, state, details)
+	})
+	if err != nil {
+		logger.Log().Errorw("Error recording webhook event", "step", state, "error", err)
+	}
 }
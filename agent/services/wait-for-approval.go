@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Humalect/humalect-core/agent/constants"
+	"github.com/Humalect/humalect-core/agent/logger"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+)
+
+const approvalPollInterval = 10 * time.Second
+
+var deploymentSetGVR = schema.GroupVersionResource{
+	Group:    "k8s.humalect.com",
+	Version:  "v1",
+	Resource: "deploymentsets",
+}
+
+// WaitForApproval blocks until the DeploymentSet is approved, either through spec.approved or the
+// approval annotation. It returns an error when the deployment is rejected or the timeout expires.
+func WaitForApproval(params constants.ParamsConfig) error {
+	if params.DeploymentSetName == "" {
+		return errors.New("DeploymentSet name is required to wait for an approval")
+	}
+	timeoutSeconds := params.ApprovalTimeoutSeconds
+	if timeoutSeconds <= 0 {
+		timeoutSeconds = constants.DefaultApprovalTimeoutSeconds
+	}
+
+	config := GetK8sConfig()
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	logger.Log().Infow("Waiting for approval", "deploymentSet", params.DeploymentSetName, "namespace", params.DeploymentSetNamespace)
+	var rejected bool
+	err = wait.PollImmediate(approvalPollInterval, time.Duration(timeoutSeconds)*time.Second, func() (bool, error) {
+		deploymentSet, err := dynamicClient.Resource(deploymentSetGVR).Namespace(params.DeploymentSetNamespace).Get(context.TODO(), params.DeploymentSetName, metav1.GetOptions{})
+		if err != nil {
+			// The api server might be briefly unreachable, keep waiting until the timeout.
+			logger.Log().Warnw("Error getting DeploymentSet", "deploymentSet", params.DeploymentSetName, "error", err)
+			return false, nil
+		}
+		approved, decided := getApprovalDecision(deploymentSet)
+		if !decided {
+			return false, nil
+		}
+		rejected = !approved
+		return true, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("approval timed out after %d seconds", timeoutSeconds)
+	}
+	if err != nil {
+		return err
+	}
+	if rejected {
+		return errors.New("deployment was rejected")
+	}
+	logger.Log().Infow("Deployment approved", "deploymentSet", params.DeploymentSetName)
+	return nil
+}
+
+func getApprovalDecision(deploymentSet *unstructured.Unstructured) (approved bool, decided bool) {
+	switch deploymentSet.GetAnnotations()[constants.ApprovalAnnotation] {
+	case constants.ApprovalAnnotationApproved:
+		return true, true
+	case constants.ApprovalAnnotationRejected:
+		return false, true
+	}
+	approved, found, err := unstructured.NestedBool(deploymentSet.Object, "spec", "approved")
+	if err != nil || !found {
+		return false, false
+	}
+	return approved, true
+}
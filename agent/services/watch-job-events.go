@@ -2,19 +2,21 @@ package services
 
 import (
 	"context"
-	"fmt"
-	"log"
 
 	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+
+	"github.com/Humalect/humalect-core/agent/logger"
 )
 
 func WatchJobEvents(namespace, jobName string) bool {
 	config := GetK8sConfig()
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		log.Fatalf("Error creating clientset: %v", err)
+		logger.Log().Errorw("Error creating clientset", "error", err)
+		return false
 	}
 	for {
 		watcher, err := clientset.BatchV1().Jobs(namespace).Watch(context.TODO(), metav1.SingleObject(metav1.ObjectMeta{Name: jobName}))
@@ -32,15 +34,21 @@ func WatchJobEvents(namespace, jobName string) bool {
 			succeeded := job.Status.Succeeded
 			failed := job.Status.Failed
 
-			if succeeded > 0 {
-				fmt.Println("Job succeeded")
-				return true
-			} else if failed > 0 {
-				fmt.Println("Job failed")
-				return false
-			} else {
-				fmt.Println("Job status:", job.Status)
+			// Failed pods are retried up to the backoff limit, only the conditions of the Job are final.
+			for _, condition := range job.Status.Conditions {
+				if condition.Status != corev1.ConditionTrue {
+					continue
+				}
+				if condition.Type == batchv1.JobComplete {
+					logger.Log().Infow("Job succeeded", "job", jobName)
+					return true
+				}
+				if condition.Type == batchv1.JobFailed {
+					logger.Log().Infow("Job failed", "job", jobName, "reason", condition.Reason, "failed", failed)
+					return false
+				}
 			}
+			logger.Log().Debugw("Job status", "job", jobName, "active", job.Status.Active, "succeeded", succeeded, "failed", failed)
 		}
 	}
 }
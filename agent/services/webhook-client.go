@@ -0,0 +1,33 @@
+package services
+
+import (
+	"sync"
+
+	"github.com/Humalect/humalect-core/agent/logger"
+	"github.com/Humalect/humalect-core/pkg/webhook"
+)
+
+// NewWebhookClient returns a client that signs with the secret from the webhook signing Secret in the
+// humalect namespace.
+func NewWebhookClient() *webhook.Client {
+	signingSecret := webhook.NewSigningSecretLoader(webhook.GetSigningSecretRef("humalect"), GetK8sConfig, func(msg string, keysAndValues ...interface{}) {
+		logger.Log().Errorw(msg, keysAndValues...)
+	})
+	client := webhook.NewClient(signingSecret)
+	client.Log = func(msg string, keysAndValues ...interface{}) {
+		logger.Log().Warnw(msg, keysAndValues...)
+	}
+	return client
+}
+
+var (
+	defaultWebhookClient     *webhook.Client
+	defaultWebhookClientOnce sync.Once
+)
+
+func getDefaultWebhookClient() *webhook.Client {
+	defaultWebhookClientOnce.Do(func() {
+		defaultWebhookClient = NewWebhookClient()
+	})
+	return defaultWebhookClient
+}
@@ -0,0 +1,9 @@
+package services
+
+import "github.com/Humalect/humalect-core/pkg/webhook"
+
+// The webhook payload is shared with the controller, it is built by webhook.BuildRequest.
+type (
+	WebhookData        = webhook.Data
+	WebhookStepDetails = webhook.StepDetails
+)
@@ -1,11 +1,19 @@
 package tasks
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"time"
 
 	"github.com/Humalect/humalect-core/agent/constants"
+	"github.com/Humalect/humalect-core/agent/logger"
 	"github.com/Humalect/humalect-core/agent/services"
 	"github.com/Humalect/humalect-core/agent/utils"
+	"github.com/Humalect/humalect-core/pkg/tracing"
+	"github.com/Humalect/humalect-core/pkg/webhook"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
 )
 
 func Deploy(config *constants.ParamsConfig) error {
@@ -37,24 +45,88 @@ func Deploy(config *constants.ParamsConfig) error {
 	// 	return err
 	// }
 
+	ctx := tracing.ContextWithTraceParent(context.Background(), config.TraceParent)
+	ctx, span := tracing.StartSpan(ctx, "agent.deploy", trace.WithAttributes(
+		attribute.String("humalect.deployment_id", config.DeploymentId),
+		attribute.String("humalect.pipeline_id", config.PipelineId),
+		attribute.String("humalect.namespace", config.Namespace),
+	))
+	defer span.End()
+
+	logger.SetStep(constants.CreatedKanikoJob)
+	_, jobSpan := tracing.StartSpan(ctx, "create-kaniko-job")
 	kanikoJobResources, err := services.CreateKanikoJob(*config)
+	tracing.EndSpan(jobSpan, err)
+	// The resources created for the build are removed whatever the outcome of the deployment is.
+	defer cleanupKanikoJobResources(kanikoJobResources)
 	if err != nil {
-		config.WebhookData = utils.UpdateStatusData(config.WebhookData, constants.CreatedKanikoJob, false)
-		services.SendWebhook(config.WebhookEndpoint, config.WebhookData, false, constants.CreatedKanikoJob)
-		fmt.Println(err)
+		logger.Log().Errorw("Failed to create kaniko job", "category", utils.GetErrorCategory(err, constants.ErrorCategoryApplyFailed), "error", err)
+		reportFailure(config, constants.CreatedKanikoJob, err, constants.ErrorCategoryApplyFailed, services.WebhookStepDetails{})
+		services.RecordDeploymentSetEvent(*config, corev1.EventTypeWarning, "KanikoJobCreateFailed", "Failed to create kaniko job: %v", err)
 		return err
 	}
-	fmt.Println("Kaniko Job Created")
+	logger.Log().Infow("Kaniko job created", "kanikoJob", kanikoJobResources.KanikoJobName, "image", kanikoJobResources.ImageReference)
+	services.RecordDeploymentSetEvent(*config, corev1.EventTypeNormal, "KanikoJobCreated", "Created kaniko job humalect/%s to build %s", kanikoJobResources.KanikoJobName, kanikoJobResources.ImageReference)
+	config.WebhookData = utils.UpdateWebhookDataField(config.WebhookData, "image", kanikoJobResources.ImageReference)
+	config.WebhookData = utils.UpdateWebhookDataField(config.WebhookData, "kanikoJobName", kanikoJobResources.KanikoJobName)
+	logger.SetStep(constants.KanikoJobExecuted)
+	buildStart := time.Now()
+	_, buildSpan := tracing.StartSpan(ctx, "build", trace.WithAttributes(
+		attribute.String("humalect.kaniko_job", kanikoJobResources.KanikoJobName),
+		attribute.String("humalect.image", kanikoJobResources.ImageReference),
+	))
 	status := services.WatchJobEvents("humalect", kanikoJobResources.KanikoJobName)
+	kanikoJobResult, err := services.GetKanikoJobResult("humalect", kanikoJobResources.KanikoJobName)
+	if err != nil {
+		logger.Log().Warnw("Failed to read the result of the kaniko job", "kanikoJob", kanikoJobResources.KanikoJobName, "error", err)
+	}
+	buildSpan.SetAttributes(attribute.String("humalect.kaniko_pod", kanikoJobResult.PodName))
+	config.WebhookData = utils.UpdateWebhookDataField(config.WebhookData, "kanikoPodName", kanikoJobResult.PodName)
 	if !status {
-		fmt.Println("Kaniko Job Failed")
-		config.WebhookData = utils.UpdateStatusData(config.WebhookData, constants.KanikoJobExecuted, false)
-		services.SendWebhook(config.WebhookEndpoint, config.WebhookData, false, constants.KanikoJobExecuted)
-		return nil
+		logger.Log().Errorw("Kaniko job failed", "kanikoJob", kanikoJobResources.KanikoJobName, "kanikoPod", kanikoJobResult.PodName, "reason", kanikoJobResult.Reason, "error", kanikoJobResult.Message)
+		message := kanikoJobResult.Message
+		if message == "" {
+			message = "kaniko job failed"
+		}
+		// A failed prepare-config container could not fetch the source code.
+		category := constants.ErrorCategoryBuildFailed
+		if kanikoJobResult.Container == services.PrepareConfigContainerName {
+			category = constants.ErrorCategorySourceAuth
+		}
+		err = utils.NewDeploymentError(category, errors.New(message))
+		tracing.EndSpan(buildSpan, err)
+		reportFailure(config, constants.KanikoJobExecuted, err, category, services.WebhookStepDetails{
+			ContainerReason: kanikoJobResult.Reason,
+			DurationSeconds: webhook.SecondsSince(buildStart),
+			LogExcerpt:      kanikoJobResult.LogExcerpt,
+		})
+		services.RecordDeploymentSetEvent(*config, corev1.EventTypeWarning, "KanikoJobFailed", "Kaniko job humalect/%s failed: %s", kanikoJobResources.KanikoJobName, message)
+		return err
 	}
-	fmt.Println("Kaniko Job Completed")
+	logger.Log().Infow("Kaniko job completed", "kanikoJob", kanikoJobResources.KanikoJobName, "kanikoPod", kanikoJobResult.PodName, "imageDigest", kanikoJobResult.ImageDigest)
+	buildSpan.SetAttributes(attribute.String("humalect.image_digest", kanikoJobResult.ImageDigest))
+	tracing.EndSpan(buildSpan, nil)
+	config.WebhookData = utils.UpdateWebhookDataField(config.WebhookData, "imageDigest", kanikoJobResult.ImageDigest)
 	config.WebhookData = utils.UpdateStatusData(config.WebhookData, constants.KanikoJobExecuted, true)
-	services.SendWebhook(config.WebhookEndpoint, config.WebhookData, true, constants.KanikoJobExecuted)
+	services.SendWebhookWithDetails(config.WebhookEndpoint, config.WebhookData, true, constants.KanikoJobExecuted, services.WebhookStepDetails{DurationSeconds: webhook.SecondsSince(buildStart)})
+	services.RecordDeploymentSetEvent(*config, corev1.EventTypeNormal, "KanikoJobSucceeded", "Kaniko job humalect/%s built %s", kanikoJobResources.KanikoJobName, kanikoJobResources.ImageReference)
+
+	if config.RequireApproval {
+		logger.SetStep(constants.WaitingForApproval)
+		config.WebhookData = utils.UpdateStatusData(config.WebhookData, constants.WaitingForApproval, true)
+		services.SendWebhook(config.WebhookEndpoint, config.WebhookData, true, constants.WaitingForApproval)
+		approvalStart := time.Now()
+		_, approvalSpan := tracing.StartSpan(ctx, "wait-for-approval")
+		err = services.WaitForApproval(*config)
+		tracing.EndSpan(approvalSpan, err)
+		if err != nil {
+			logger.Log().Errorw("Deployment was not approved", "error", err)
+			reportFailure(config, constants.DeploymentApproved, err, "", services.WebhookStepDetails{Reason: "ApprovalNotGranted", DurationSeconds: webhook.SecondsSince(approvalStart)})
+			return err
+		}
+		config.WebhookData = utils.UpdateStatusData(config.WebhookData, constants.DeploymentApproved, true)
+		services.SendWebhookWithDetails(config.WebhookEndpoint, config.WebhookData, true, constants.DeploymentApproved, services.WebhookStepDetails{DurationSeconds: webhook.SecondsSince(approvalStart)})
+	}
 
 	// awsSecretCredentials, err := services.GetAwsSecretCredentials(config)
 	// if err != nil {
@@ -82,22 +154,46 @@ func Deploy(config *constants.ParamsConfig) error {
 	// 	return err
 	// }
 
-	_, err = services.CreateK8sApplication(config, kanikoJobResources, utils.UpdateStatusData(config.WebhookData, constants.CreatedApplicationCrd, true))
-	if err != nil {
-		fmt.Println(err)
-		config.WebhookData = utils.UpdateStatusData(config.WebhookData, constants.CreatedApplicationCrd, false)
-		services.SendWebhook(config.WebhookEndpoint, config.WebhookData, false, constants.CreatedApplicationCrd)
-		return err
-	}
-	fmt.Println("Application created")
-	err = services.CleanupKanikoJobResources(kanikoJobResources)
-	// TODO send webhook here
+	logger.SetStep(constants.CreatedApplicationCrd)
+	// The Application continues the trace under this span, so its rollout shows up in the same trace.
+	applicationCtx, applicationSpan := tracing.StartSpan(ctx, "create-application")
+	_, err = services.CreateK8sApplication(config, kanikoJobResources, utils.UpdateStatusData(config.WebhookData, constants.CreatedApplicationCrd, true), tracing.GetTraceParent(applicationCtx))
+	tracing.EndSpan(applicationSpan, err)
 	if err != nil {
-		fmt.Println(err)
-		// services.SendWebhook(config.WebhookEndpoint, config.WebhookData, false, constants.CreatedApplicationCrd)
+		err = utils.NewDeploymentError(constants.ErrorCategoryApplyFailed, err)
+		logger.Log().Errorw("Failed to create application", "application", config.K8sAppName, "error", err)
+		reportFailure(config, constants.CreatedApplicationCrd, err, constants.ErrorCategoryApplyFailed, services.WebhookStepDetails{})
 		return err
 	}
+	logger.Log().Infow("Application created", "application", config.K8sAppName, "namespace", config.Namespace)
 	config.WebhookData = utils.UpdateStatusData(config.WebhookData, constants.CreatedApplicationCrd, true)
 	services.SendWebhook(config.WebhookEndpoint, config.WebhookData, true, constants.CreatedApplicationCrd)
 	return nil
 }
+
+// ReportSetupFailure ends the deployment when the agent could not be set up, before Deploy created anything
+// that has to be cleaned up.
+func ReportSetupFailure(config *constants.ParamsConfig, err error) {
+	logger.SetStep(constants.CreatedKanikoJob)
+	reportFailure(config, constants.CreatedKanikoJob, err, constants.ErrorCategoryBuildFailed, services.WebhookStepDetails{})
+	services.RecordDeploymentSetEvent(*config, corev1.EventTypeWarning, "AgentSetupFailed", "Failed to set up the agent: %v", err)
+}
+
+// reportFailure sends the failure webhook of the step, which ends the deployment. The category of the error,
+// or the fallback category, is sent as the reason of the step unless the details already have a reason.
+func reportFailure(config *constants.ParamsConfig, step string, err error, fallbackCategory string, details services.WebhookStepDetails) {
+	details.Error = err.Error()
+	details.Terminal = true
+	if details.Reason == "" {
+		details.Reason = utils.GetErrorCategory(err, fallbackCategory)
+	}
+	config.WebhookData = utils.UpdateStatusData(config.WebhookData, step, false)
+	services.SendWebhookWithDetails(config.WebhookEndpoint, config.WebhookData, false, step, details)
+}
+
+// cleanupKanikoJobResources only logs failures, the outcome of the deployment has been reported already.
+func cleanupKanikoJobResources(kanikoJobResources services.CreateJobConfig) {
+	if err := services.CleanupKanikoJobResources(kanikoJobResources); err != nil {
+		logger.Log().Errorw("Failed to clean up kaniko job resources", "error", err)
+	}
+}
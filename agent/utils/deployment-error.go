@@ -0,0 +1,40 @@
+package utils
+
+import "errors"
+
+// DeploymentError is the error of a deployment step together with its category, one of the
+// constants.ErrorCategory values. The category is reported as the reason of the failed step.
+type DeploymentError struct {
+	Category string
+	Err      error
+}
+
+func (e *DeploymentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DeploymentError) Unwrap() error {
+	return e.Err
+}
+
+// NewDeploymentError adds the category to err. An error that already has a category keeps it, so the
+// category is decided where the error happened and not where it is passed on.
+func NewDeploymentError(category string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var deploymentError *DeploymentError
+	if errors.As(err, &deploymentError) {
+		return err
+	}
+	return &DeploymentError{Category: category, Err: err}
+}
+
+// GetErrorCategory returns the category of err, or the fallback when err has none.
+func GetErrorCategory(err error, fallback string) string {
+	var deploymentError *DeploymentError
+	if errors.As(err, &deploymentError) {
+		return deploymentError.Category
+	}
+	return fallback
+}
@@ -3,6 +3,8 @@ package utils
 import (
 	"errors"
 	"fmt"
+
+	"github.com/Humalect/humalect-core/agent/logger"
 )
 
 func GetArtifactsRepoLink(cloudProvider string,
@@ -16,7 +18,7 @@ func GetArtifactsRepoLink(cloudProvider string,
 	} else if cloudProvider == "azure" {
 		artifactsRepoLink = fmt.Sprintf("%s.azurecr.io/%s:%s", azureAcrRegistryName, artifactsRepositoryName, commitId)
 	} else {
-		fmt.Println("Error: Invalid cloudProvider")
+		logger.Log().Errorw("Invalid cloudProvider", "cloudProvider", cloudProvider)
 		return "", errors.New("Error: invalid cloud Id")
 	}
 	return artifactsRepoLink, nil
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"flag"
+	"os"
 
 	"github.com/Humalect/humalect-core/agent/constants"
 )
@@ -9,15 +10,15 @@ import (
 func ParseCLIArguments() *constants.ParamsConfig {
 	config := &constants.ParamsConfig{}
 	flag.StringVar(&config.SecretsProvider, "secretsProvider", "", "This is an optional parameter which can have 2 values either aws or azure and it represents the main secrets provider to be used(by default it would be same as cloud provider).")
-	flag.StringVar(&config.EcrCredentials, "ecrCredentials", "", "This is an optional parameter and it would only be passed when the artifactsRegistryProvider is aws and it represents the credentials for the AWS ECR registry.")
-	flag.StringVar(&config.DockerHubCredentials, "dockerHubCredentials", "", "This is an optional parameter and it would only be passed when the artifactsRegistryProvider is dockerhub and it represents the credentials for the dockerhub registry.")
-	flag.StringVar(&config.AcrCredentials, "acrCredentials", "", "This is an optional parameter and it would only be passed when the artifactsRegistryProvider is azure and it represents the credentials for the Azure ACR registry.")
-	flag.StringVar(&config.AwsSecretCredentials, "awsSecretCredentials", "", "This is an optional parameter and it would only be passed when the Secrets Provider is aws and it represents the credentials for the AWS Temporary login.")
-	flag.StringVar(&config.AzureVaultCredentials, "azureVaultCredentials", "", "This is an optional parameter and it would only be passed when the Secrets Provider is azure vault and it represents the credentials for the Azure Vault.")
+	flag.StringVar(&config.EcrCredentials, "ecrCredentials", "", "This is an optional parameter and it would only be passed when the artifactsRegistryProvider is aws and it represents the credentials for the AWS ECR registry. The HUMALECT_ECR_CREDENTIALS environment variable is used when it is not passed.")
+	flag.StringVar(&config.DockerHubCredentials, "dockerHubCredentials", "", "This is an optional parameter and it would only be passed when the artifactsRegistryProvider is dockerhub and it represents the credentials for the dockerhub registry. The HUMALECT_DOCKER_HUB_CREDENTIALS environment variable is used when it is not passed.")
+	flag.StringVar(&config.AcrCredentials, "acrCredentials", "", "This is an optional parameter and it would only be passed when the artifactsRegistryProvider is azure and it represents the credentials for the Azure ACR registry. The HUMALECT_ACR_CREDENTIALS environment variable is used when it is not passed.")
+	flag.StringVar(&config.AwsSecretCredentials, "awsSecretCredentials", "", "This is an optional parameter and it would only be passed when the Secrets Provider is aws and it represents the credentials for the AWS Temporary login. The HUMALECT_AWS_SECRET_CREDENTIALS environment variable is used when it is not passed.")
+	flag.StringVar(&config.AzureVaultCredentials, "azureVaultCredentials", "", "This is an optional parameter and it would only be passed when the Secrets Provider is azure vault and it represents the credentials for the Azure Vault. The HUMALECT_AZURE_VAULT_CREDENTIALS environment variable is used when it is not passed.")
 	flag.StringVar(&config.CloudProvider, "cloudProvider", "", "This is a required parameter which can have 2 values either aws or azure and it represents the main cloud provider to be used.")
 	flag.StringVar(&config.SourceCodeRepositoryName, "sourceCodeRepositoryName", "", "The repository name of your github, gitlab or bitbucket repository")
 	flag.StringVar(&config.SourceCodeProvider, "sourceCodeProvider", "", "This is a required parameter that represents the name of the version control system portal and it could be either github  or gitlab or bitbucket")
-	flag.StringVar(&config.SourceCodeToken, "sourceCodeToken", "", "This is a required parameter that represents the token to pull the source code from the version control system portal namely github, gitlab and bitbucket.")
+	flag.StringVar(&config.SourceCodeToken, "sourceCodeToken", "", "This is a required parameter that represents the token to pull the source code from the version control system portal namely github, gitlab and bitbucket. The HUMALECT_SOURCE_CODE_TOKEN environment variable is used when it is not passed.")
 	flag.StringVar(&config.ArtifactsRegistryProvider, "artifactsRegistryProvider", "", "This is a required parameter that represents the name of the docker registry provider and it could be either dockerhub or azure or aws.")
 	flag.StringVar(&config.SourceCodeOrgName, "sourceCodeOrgName", "", "This is a required parameter that represents the organization or user name for the version control system portal namely github, gitlab and bitbucket.")
 	flag.StringVar(&config.CommitId, "commitId", "", "This is a required parameter that represents the commitId for the version control system portal namely github, gitlab and bitbucket.")
@@ -38,7 +39,34 @@ func ParseCLIArguments() *constants.ParamsConfig {
 	flag.StringVar(&config.WebhookEndpoint, "webhookEndpoint", "", "This is an optional parameter and represents the endpoint which can be used to send webhook notifications.")
 	flag.StringVar(&config.PipelineId, "pipelineId", "", "This is a required parameter that represents the pipeline if for the deployment.")
 	flag.StringVar(&config.WebhookData, "webhookData", "", "This is an optional parameter and represents the data that is to be sent to the webhook endpoint(in json string format).")
+	flag.StringVar(&config.ApplyMode, "applyMode", "", "This is an optional parameter and represents how the Application is rolled out, plan only computes the diff against the live resources while apply(default) also applies them.")
+	flag.StringVar(&config.DeploymentSetName, "deploymentSetName", "", "This is an optional parameter and represents the name of the DeploymentSet that started this deployment.")
+	flag.StringVar(&config.DeploymentSetNamespace, "deploymentSetNamespace", "", "This is an optional parameter and represents the namespace of the DeploymentSet that started this deployment.")
+	flag.BoolVar(&config.RequireApproval, "requireApproval", false, "This is an optional boolean parameter and when set the deployment waits for a manual approval on the DeploymentSet after the docker image is built.")
+	flag.Int64Var(&config.ApprovalTimeoutSeconds, "approvalTimeoutSeconds", 0, "This is an optional parameter and represents the number of seconds to wait for an approval before the deployment is failed(it is set to 24 hours if not passed).")
+	flag.StringVar(&config.Hooks, "hooks", "", "This is an optional parameter and represents the pre-deploy and post-deploy hooks of the Application(in json string format).")
+	flag.StringVar(&config.SmokeTest, "smokeTest", "", "This is an optional parameter and represents the http smoke test that runs after the rollout of the Application(in json string format).")
+	flag.StringVar(&config.WebhookFormat, "webhookFormat", "", "This is an optional parameter and represents the payload format of the webhooks, legacy(default) or cloudevents.")
+	flag.StringVar(&config.WebhookContentMode, "webhookContentMode", "", "This is an optional parameter and represents how cloudevents are sent, structured(default) or binary.")
+	flag.StringVar(&config.TraceParent, "traceParent", "", "This is an optional parameter and represents the W3C trace context of the deployment, the spans of the agent and the Application are added to this trace.")
+	flag.StringVar(&config.LogFormat, "logFormat", "", "This is an optional parameter and represents the format of the logs, json(default) or console. The LOG_FORMAT environment variable is used when it is not passed.")
+	flag.StringVar(&config.LogLevel, "logLevel", "", "This is an optional parameter and represents the minimum level of the logs, debug, info(default), warn or error. The LOG_LEVEL environment variable is used when it is not passed.")
+	flag.StringVar(&config.Build, "build", "", "This is an optional parameter and represents the build settings of the kaniko job(in json string format).")
+	flag.StringVar(&config.Network, "network", "", "This is an optional parameter and represents the CA bundle, proxy and insecure registries of the cluster(in json string format).")
 
 	flag.Parse()
+	// The DeploymentSet passes the credentials from a Secret, so they are not part of the Job spec.
+	for flagValue, envName := range map[*string]string{
+		&config.SourceCodeToken:       constants.SourceCodeTokenEnv,
+		&config.EcrCredentials:        constants.EcrCredentialsEnv,
+		&config.AcrCredentials:        constants.AcrCredentialsEnv,
+		&config.DockerHubCredentials:  constants.DockerHubCredentialsEnv,
+		&config.AwsSecretCredentials:  constants.AwsSecretCredentialsEnv,
+		&config.AzureVaultCredentials: constants.AzureVaultCredentialsEnv,
+	} {
+		if *flagValue == "" {
+			*flagValue = os.Getenv(envName)
+		}
+	}
 	return config
 }
@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"encoding/json"
+
+	"github.com/Humalect/humalect-core/agent/constants"
+	"github.com/Humalect/humalect-core/pkg/redact"
+)
+
+// RedactCredentials registers the credentials passed to the agent, values fetched with them are
+// registered where they are fetched.
+func RedactCredentials(config *constants.ParamsConfig) {
+	var ecrCredentials constants.EcrCredentials
+	_ = json.Unmarshal([]byte(config.EcrCredentials), &ecrCredentials)
+	var acrCredentials constants.AcrCredentials
+	_ = json.Unmarshal([]byte(config.AcrCredentials), &acrCredentials)
+	var awsSecretCredentials constants.AwsSecretCredentials
+	_ = json.Unmarshal([]byte(config.AwsSecretCredentials), &awsSecretCredentials)
+	var azureVaultCredentials constants.AzureVaultCredentials
+	_ = json.Unmarshal([]byte(config.AzureVaultCredentials), &azureVaultCredentials)
+
+	redact.Add(
+		config.SourceCodeToken,
+		ecrCredentials.AccessKey,
+		ecrCredentials.SecretKey,
+		acrCredentials.ManagementScopeToken,
+		awsSecretCredentials.AccessKey,
+		awsSecretCredentials.SecretKey,
+		azureVaultCredentials.Token,
+	)
+}
@@ -2,14 +2,15 @@ package utils
 
 import (
 	"encoding/json"
-	"fmt"
+
+	"github.com/Humalect/humalect-core/agent/logger"
 )
 
 func UpdateStatusData(webhookDataString string, step string, success bool) string {
 	var WebhookData map[string]interface{}
 	err := json.Unmarshal([]byte(webhookDataString), &WebhookData)
 	if err != nil {
-		fmt.Println("Some error occured while parsing webhook data:= ", err)
+		logger.Log().Errorw("Error parsing webhook data", "error", err)
 	}
 	if _, ok := WebhookData["statusData"]; ok {
 		WebhookData["statusData"].(map[string]interface{})[step] = success
@@ -21,3 +22,17 @@ func UpdateStatusData(webhookDataString string, step string, success bool) strin
 	jsonData, err := json.Marshal(WebhookData)
 	return string(jsonData)
 }
+
+func UpdateWebhookDataField(webhookDataString string, key string, value interface{}) string {
+	var WebhookData map[string]interface{}
+	err := json.Unmarshal([]byte(webhookDataString), &WebhookData)
+	if err != nil {
+		logger.Log().Errorw("Error parsing webhook data", "error", err)
+	}
+	if WebhookData == nil {
+		WebhookData = map[string]interface{}{}
+	}
+	WebhookData[key] = value
+	jsonData, err := json.Marshal(WebhookData)
+	return string(jsonData)
+}
@@ -62,12 +62,83 @@ type ApplicationSpec struct {
 	WebhookData              string                     `json:"webhookData"`
 	PipelineId               string                     `json:"pipelineId"`
 	DeploymentId             string                     `json:"deploymentId"`
+	ApplyMode                string                     `json:"applyMode,omitempty"`
+	Hooks                    HooksSpec                  `json:"hooks,omitempty"`
+	SmokeTest                *SmokeTestSpec             `json:"smokeTest,omitempty"`
+	WebhookFormat            string                     `json:"webhookFormat,omitempty"`
+	WebhookContentMode       string                     `json:"webhookContentMode,omitempty"`
+	TraceParent              string                     `json:"traceParent,omitempty"`
+}
+
+// HookSpec describes a Job that runs around the Deployment update. When Image is empty the
+// image of the first container of the Deployment is used.
+type HookSpec struct {
+	Name                  string          `json:"name"`
+	Image                 string          `json:"image,omitempty"`
+	Command               []string        `json:"command,omitempty"`
+	Args                  []string        `json:"args,omitempty"`
+	Env                   []corev1.EnvVar `json:"env,omitempty"`
+	BackoffLimit          *int32          `json:"backoffLimit,omitempty"`
+	ActiveDeadlineSeconds *int64          `json:"activeDeadlineSeconds,omitempty"`
+}
+
+type HooksSpec struct {
+	PreDeploy     []HookSpec `json:"preDeploy,omitempty"`
+	PostDeploy    []HookSpec `json:"postDeploy,omitempty"`
+	FailurePolicy string     `json:"failurePolicy,omitempty"`
+}
+
+// SmokeTestSpec describes an HTTP check that runs once the rollout completed. Target is either service
+// (default) or ingress. A failed smoke test is handled with the failure policy of the hooks.
+type SmokeTestSpec struct {
+	Path                 string `json:"path,omitempty"`
+	ExpectedStatus       int    `json:"expectedStatus,omitempty"`
+	BodyRegex            string `json:"bodyRegex,omitempty"`
+	Retries              int    `json:"retries,omitempty"`
+	RetryIntervalSeconds int    `json:"retryIntervalSeconds,omitempty"`
+	TimeoutSeconds       int    `json:"timeoutSeconds,omitempty"`
+	Target               string `json:"target,omitempty"`
+}
+
+// ResourceDiff describes how a live resource differs from the desired one.
+// Secrets are compared by key only, their values are never exposed.
+type ResourceDiff struct {
+	Kind        string   `json:"kind"`
+	Name        string   `json:"name"`
+	Action      string   `json:"action"`
+	Fields      []string `json:"fields,omitempty"`
+	AddedKeys   []string `json:"addedKeys,omitempty"`
+	RemovedKeys []string `json:"removedKeys,omitempty"`
+	ChangedKeys []string `json:"changedKeys,omitempty"`
+}
+
+// DeliveredNotification records the outcome of a step that was already sent for a deployment,
+// so resyncs and controller restarts don't send the same notification again.
+type DeliveredNotification struct {
+	DeploymentId string      `json:"deploymentId"`
+	Step         string      `json:"step"`
+	Success      bool        `json:"success"`
+	SentTime     metav1.Time `json:"sentTime,omitempty"`
+}
+
+// SmokeTestStatus counts the attempts of the smoke test for a generation. A reconcile runs at most one
+// attempt, the next one is requeued after the retry interval.
+type SmokeTestStatus struct {
+	ObservedGeneration int64        `json:"observedGeneration,omitempty"`
+	Attempts           int          `json:"attempts,omitempty"`
+	LastAttemptTime    *metav1.Time `json:"lastAttemptTime,omitempty"`
 }
 
 // ApplicationStatus defines the observed state of Application
 type ApplicationStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
+	Phase              string                  `json:"phase,omitempty"`
+	Diff               []ResourceDiff          `json:"diff,omitempty"`
+	Conditions         []metav1.Condition      `json:"conditions,omitempty"`
+	ObservedGeneration int64                   `json:"observedGeneration,omitempty"`
+	Notifications      []DeliveredNotification `json:"notifications,omitempty"`
+	SmokeTest          *SmokeTestStatus        `json:"smokeTest,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -17,6 +17,8 @@ limitations under the License.
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -60,6 +62,81 @@ type AzureVaultCredentials struct {
 	Name  string `json:"name,omitempty"`
 }
 
+// BuildSpec configures the kaniko job that builds the image. Build secrets are mounted as files in
+// /run/secrets of the kaniko container, RUN commands read them from /run/secrets/<key>. Kaniko ignores
+// RUN --mount, so type=secret mounts of BuildKit Dockerfiles are not supported.
+type BuildSpec struct {
+	// BuildArgsFromSecrets are the keys of the build secrets that are not sensitive and are also passed
+	// as build args. Build args end up in the image history, so this is opt-in per key.
+	BuildArgsFromSecrets []string `json:"buildArgsFromSecrets,omitempty"`
+	// Args are plain build args like NODE_ENV. COMMIT_SHA and PIPELINE_ID are always passed unless
+	// they are set here.
+	Args map[string]string `json:"args,omitempty"`
+	// Target is the stage of a multi-stage Dockerfile that is built, the last stage when empty.
+	Target string `json:"target,omitempty"`
+	// ContextPath is the directory of the repository that is the build context, like services/api.
+	// The root of the repository is used when empty.
+	ContextPath string `json:"contextPath,omitempty"`
+	// DockerfilePath is the path of the Dockerfile relative to the root of the repository, it defaults
+	// to the Dockerfile of the context. A DockerManifest is written to this path.
+	DockerfilePath string         `json:"dockerfilePath,omitempty"`
+	Cache          BuildCacheSpec `json:"cache,omitempty"`
+	// Pod configures the kaniko pod and the agent Job. Fields that are not set are taken from the
+	// pod key of the humalect-build-defaults ConfigMap of the humalect namespace.
+	Pod BuildPodSpec `json:"pod,omitempty"`
+	// Images overrides the images of the git, kaniko, warmer and agent containers.
+	Images BuildImagesSpec `json:"images,omitempty"`
+	// KanikoFlags are passed on to kaniko, like --snapshot-mode=redo or --registry-mirror=mirror.local.
+	// Flags the agent sets itself, like --destination, are rejected.
+	KanikoFlags []string `json:"kanikoFlags,omitempty"`
+}
+
+// BuildImagesSpec overrides the images of a deployment, like images of a mirror pinned by digest.
+// Images that are not set are taken from the humalect-build-defaults ConfigMap, then the built-in ones.
+type BuildImagesSpec struct {
+	Git    string `json:"git,omitempty"`
+	Kaniko string `json:"kaniko,omitempty"`
+	Warmer string `json:"warmer,omitempty"`
+	Agent  string `json:"agent,omitempty"`
+}
+
+// BuildPodSpec configures the pods of a deployment. Resources, TimeoutSeconds and BackoffLimit apply
+// to the kaniko pod, the scheduling settings to both the kaniko pod and the agent pod.
+type BuildPodSpec struct {
+	// Resources are the resources of the kaniko container and of the prepare-config and cache-warmer
+	// init containers. Init containers run one after another, so they do not add to the requests of the pod.
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+	// AgentResources are the resources of the agent container.
+	AgentResources corev1.ResourceRequirements `json:"agentResources,omitempty"`
+	// TimeoutSeconds is the activeDeadlineSeconds of the kaniko Job. The agent Job additionally gets the
+	// approval timeout when the deployment requires an approval.
+	TimeoutSeconds *int64 `json:"timeoutSeconds,omitempty"`
+	// BackoffLimit is how often a failed build is retried. The agent is never retried, a second agent
+	// would start the deployment again.
+	BackoffLimit      *int32              `json:"backoffLimit,omitempty"`
+	NodeSelector      map[string]string   `json:"nodeSelector,omitempty"`
+	Tolerations       []corev1.Toleration `json:"tolerations,omitempty"`
+	Affinity          *corev1.Affinity    `json:"affinity,omitempty"`
+	PriorityClassName string              `json:"priorityClassName,omitempty"`
+	// WorkspaceSizeLimit limits the ephemeral storage of the checked out repository.
+	WorkspaceSizeLimit *resource.Quantity `json:"workspaceSizeLimit,omitempty"`
+}
+
+// BuildCacheSpec configures the layer cache of kaniko. Cached layers are pushed with the registry
+// credentials of the artifacts repository.
+type BuildCacheSpec struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Repo is the repository of the cached layers, <artifacts repository>/cache when empty.
+	Repo string `json:"repo,omitempty"`
+	// TTL is how long cached layers are used, like 168h. Kaniko uses two weeks when empty.
+	TTL string `json:"ttl,omitempty"`
+	// WarmBaseImages pulls the base images of the Dockerfile with the kaniko warmer before the build.
+	WarmBaseImages bool `json:"warmBaseImages,omitempty"`
+	// BaseImageCacheClaim is a PersistentVolumeClaim in the humalect namespace that keeps the warmed
+	// base images between builds, they are only kept for the build when empty.
+	BaseImageCacheClaim string `json:"baseImageCacheClaim,omitempty"`
+}
+
 type DeploymentSetSpec struct {
 	ArtifactsRegistryProvider string                     `json:"artifactsRegistryProvider,omitempty"`
 	SecretsProvider           string                     `json:"secretsProvider,omitempty"`
@@ -92,12 +169,25 @@ type DeploymentSetSpec struct {
 	WebhookData               string                     `json:"webhookData"`
 	WebhookEndpoint           string                     `json:"webhookEndpoint"`
 	PipelineId                string                     `json:"pipelineId"`
+	ApplyMode                 string                     `json:"applyMode,omitempty"`
+	RequireApproval           bool                       `json:"requireApproval,omitempty"`
+	ApprovalTimeoutSeconds    int64                      `json:"approvalTimeoutSeconds,omitempty"`
+	Approved                  *bool                      `json:"approved,omitempty"`
+	Hooks                     HooksSpec                  `json:"hooks,omitempty"`
+	SmokeTest                 *SmokeTestSpec             `json:"smokeTest,omitempty"`
+	WebhookFormat             string                     `json:"webhookFormat,omitempty"`
+	WebhookContentMode        string                     `json:"webhookContentMode,omitempty"`
+	Build                     BuildSpec                  `json:"build,omitempty"`
 }
 
 // DeploymentSetStatus defines the observed state of DeploymentSet
 type DeploymentSetStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
+	Phase      string             `json:"phase,omitempty"`
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// TraceParent is the W3C trace context of the root span of the deployment.
+	TraceParent string `json:"traceParent,omitempty"`
 }
 
 //+kubebuilder:object:root=true
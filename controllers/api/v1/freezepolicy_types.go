@@ -0,0 +1,75 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FreezeWindow is a recurring freeze, it starts every time the cron schedule fires and lasts for duration.
+type FreezeWindow struct {
+	Schedule string `json:"schedule"`
+	Duration string `json:"duration"`
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// FreezePeriod is a one-off freeze between start and end.
+type FreezePeriod struct {
+	Start  metav1.Time `json:"start"`
+	End    metav1.Time `json:"end"`
+	Reason string      `json:"reason,omitempty"`
+}
+
+// FreezePolicySpec defines the desired state of FreezePolicy.
+// A policy created in the controller namespace applies to the whole cluster (or to Namespaces when set),
+// a policy created in any other namespace only applies to deployments in that namespace.
+type FreezePolicySpec struct {
+	Windows    []FreezeWindow `json:"windows,omitempty"`
+	Periods    []FreezePeriod `json:"periods,omitempty"`
+	Namespaces []string       `json:"namespaces,omitempty"`
+	Action     string         `json:"action,omitempty"`
+	Reason     string         `json:"reason,omitempty"`
+}
+
+// FreezePolicyStatus defines the observed state of FreezePolicy
+type FreezePolicyStatus struct {
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// FreezePolicy is the Schema for the freezepolicies API
+type FreezePolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FreezePolicySpec   `json:"spec,omitempty"`
+	Status FreezePolicyStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// FreezePolicyList contains a list of FreezePolicy
+type FreezePolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FreezePolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FreezePolicy{}, &FreezePolicyList{})
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NotificationHeader is sent with every notification of a sink. The value can be read from a Secret
+// in the namespace of the policy, for auth tokens.
+type NotificationHeader struct {
+	Name      string                    `json:"name"`
+	Value     string                    `json:"value,omitempty"`
+	ValueFrom *corev1.SecretKeySelector `json:"valueFrom,omitempty"`
+}
+
+// NotificationFilter selects the events a sink is notified about. Empty fields match every event.
+type NotificationFilter struct {
+	Steps        []string `json:"steps,omitempty"`
+	Namespaces   []string `json:"namespaces,omitempty"`
+	OnlyFailures bool     `json:"onlyFailures,omitempty"`
+}
+
+// NotificationSink is an endpoint deployment events are sent to.
+// Type is one of Webhook, Slack, Teams, NATS or Kafka. For NATS and Kafka sinks URL lists the comma separated
+// servers or brokers and Topic is the subject or topic events are published to. The subject of a NATS sink
+// has to be stored by a JetStream stream, events are only delivered once the stream acknowledged them.
+// Template is a Go template rendered with the event, it is used as the whole body for Webhook, NATS and Kafka
+// sinks and as the message text for Slack and Teams sinks.
+type NotificationSink struct {
+	Name     string                    `json:"name"`
+	Type     string                    `json:"type"`
+	URL      string                    `json:"url,omitempty"`
+	URLFrom  *corev1.SecretKeySelector `json:"urlFrom,omitempty"`
+	Topic    string                    `json:"topic,omitempty"`
+	Headers  []NotificationHeader      `json:"headers,omitempty"`
+	Filter   NotificationFilter        `json:"filter,omitempty"`
+	Template string                    `json:"template,omitempty"`
+}
+
+// NotificationPolicySpec defines the desired state of NotificationPolicy.
+// A policy created in the controller namespace receives the events of the whole cluster,
+// a policy created in any other namespace only receives the events of deployments in that namespace.
+type NotificationPolicySpec struct {
+	Sinks []NotificationSink `json:"sinks"`
+}
+
+// NotificationPolicyStatus defines the observed state of NotificationPolicy
+type NotificationPolicyStatus struct {
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// NotificationPolicy is the Schema for the notificationpolicies API
+type NotificationPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NotificationPolicySpec   `json:"spec,omitempty"`
+	Status NotificationPolicyStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// NotificationPolicyList contains a list of NotificationPolicy
+type NotificationPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NotificationPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NotificationPolicy{}, &NotificationPolicyList{})
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WebhookEvent is the deployment step a delivery was recorded for, notification policies are matched against it.
+type WebhookEvent struct {
+	Namespace       string `json:"namespace,omitempty"`
+	DeploymentId    string `json:"deploymentId,omitempty"`
+	PipelineId      string `json:"pipelineId,omitempty"`
+	ManagedBy       string `json:"managedBy,omitempty"`
+	Step            string `json:"step"`
+	Success         bool   `json:"success"`
+	Error           string `json:"error,omitempty"`
+	Reason          string `json:"reason,omitempty"`
+	DurationSeconds int64  `json:"durationSeconds,omitempty"`
+	ElapsedSeconds  int64  `json:"elapsedSeconds,omitempty"`
+	CommitId        string `json:"commitId,omitempty"`
+	Image           string `json:"image,omitempty"`
+	ImageDigest     string `json:"imageDigest,omitempty"`
+	// Terminal marks the event that ends the deployment, the outcome of a deployment is counted at it.
+	Terminal bool `json:"terminal,omitempty"`
+}
+
+// WebhookDeliverySink points a delivery at a sink of a NotificationPolicy. The URL and headers of the sink
+// are resolved when the delivery is sent so values from Secrets are never stored on the delivery.
+type WebhookDeliverySink struct {
+	PolicyNamespace string `json:"policyNamespace"`
+	PolicyName      string `json:"policyName"`
+	SinkName        string `json:"sinkName"`
+	Type            string `json:"type,omitempty"`
+}
+
+// WebhookDeliverySpec defines the desired state of WebhookDelivery.
+// Payload is the JSON body that is posted to Endpoint together with Headers, or to the sink of a
+// NotificationPolicy when Sink is set. Deliveries with an Event are fanned out to the matching sinks.
+type WebhookDeliverySpec struct {
+	Endpoint       string               `json:"endpoint,omitempty"`
+	Payload        string               `json:"payload"`
+	Headers        map[string]string    `json:"headers,omitempty"`
+	IdempotencyKey string               `json:"idempotencyKey,omitempty"`
+	DeploymentId   string               `json:"deploymentId,omitempty"`
+	Step           string               `json:"step,omitempty"`
+	Event          *WebhookEvent        `json:"event,omitempty"`
+	Sink           *WebhookDeliverySink `json:"sink,omitempty"`
+}
+
+// WebhookDeliveryStatus defines the observed state of WebhookDelivery
+type WebhookDeliveryStatus struct {
+	State              string       `json:"state,omitempty"`
+	Attempts           int          `json:"attempts,omitempty"`
+	LastAttemptTime    *metav1.Time `json:"lastAttemptTime,omitempty"`
+	DeliveredTime      *metav1.Time `json:"deliveredTime,omitempty"`
+	LastResponseStatus int          `json:"lastResponseStatus,omitempty"`
+	LastError          string       `json:"lastError,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Step",type=string,JSONPath=`.spec.step`
+//+kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.state`
+//+kubebuilder:printcolumn:name="Attempts",type=integer,JSONPath=`.status.attempts`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// WebhookDelivery is the Schema for the webhookdeliveries API
+type WebhookDelivery struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WebhookDeliverySpec   `json:"spec,omitempty"`
+	Status WebhookDeliveryStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// WebhookDeliveryList contains a list of WebhookDelivery
+type WebhookDeliveryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WebhookDelivery `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WebhookDelivery{}, &WebhookDeliveryList{})
+}
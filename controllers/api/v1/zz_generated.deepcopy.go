@@ -22,6 +22,8 @@ limitations under the License.
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -46,7 +48,7 @@ func (in *Application) DeepCopyInto(out *Application) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Application.
@@ -117,6 +119,12 @@ func (in *ApplicationSpec) DeepCopyInto(out *ApplicationSpec) {
 		*out = make([]SecretConfig, len(*in))
 		copy(*out, *in)
 	}
+	in.Hooks.DeepCopyInto(&out.Hooks)
+	if in.SmokeTest != nil {
+		in, out := &in.SmokeTest, &out.SmokeTest
+		*out = new(SmokeTestSpec)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationSpec.
@@ -132,6 +140,32 @@ func (in *ApplicationSpec) DeepCopy() *ApplicationSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ApplicationStatus) DeepCopyInto(out *ApplicationStatus) {
 	*out = *in
+	if in.Diff != nil {
+		in, out := &in.Diff, &out.Diff
+		*out = make([]ResourceDiff, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Notifications != nil {
+		in, out := &in.Notifications, &out.Notifications
+		*out = make([]DeliveredNotification, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SmokeTest != nil {
+		in, out := &in.SmokeTest, &out.SmokeTest
+		*out = new(SmokeTestStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationStatus.
@@ -174,13 +208,145 @@ func (in *AzureVaultCredentials) DeepCopy() *AzureVaultCredentials {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuildCacheSpec) DeepCopyInto(out *BuildCacheSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuildCacheSpec.
+func (in *BuildCacheSpec) DeepCopy() *BuildCacheSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BuildCacheSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuildImagesSpec) DeepCopyInto(out *BuildImagesSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuildImagesSpec.
+func (in *BuildImagesSpec) DeepCopy() *BuildImagesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BuildImagesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuildPodSpec) DeepCopyInto(out *BuildPodSpec) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+	in.AgentResources.DeepCopyInto(&out.AgentResources)
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.BackoffLimit != nil {
+		in, out := &in.BackoffLimit, &out.BackoffLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]corev1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(corev1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WorkspaceSizeLimit != nil {
+		in, out := &in.WorkspaceSizeLimit, &out.WorkspaceSizeLimit
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuildPodSpec.
+func (in *BuildPodSpec) DeepCopy() *BuildPodSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BuildPodSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuildSpec) DeepCopyInto(out *BuildSpec) {
+	*out = *in
+	if in.BuildArgsFromSecrets != nil {
+		in, out := &in.BuildArgsFromSecrets, &out.BuildArgsFromSecrets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	out.Cache = in.Cache
+	in.Pod.DeepCopyInto(&out.Pod)
+	out.Images = in.Images
+	if in.KanikoFlags != nil {
+		in, out := &in.KanikoFlags, &out.KanikoFlags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuildSpec.
+func (in *BuildSpec) DeepCopy() *BuildSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BuildSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeliveredNotification) DeepCopyInto(out *DeliveredNotification) {
+	*out = *in
+	in.SentTime.DeepCopyInto(&out.SentTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeliveredNotification.
+func (in *DeliveredNotification) DeepCopy() *DeliveredNotification {
+	if in == nil {
+		return nil
+	}
+	out := new(DeliveredNotification)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DeploymentSet) DeepCopyInto(out *DeploymentSet) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentSet.
@@ -259,6 +425,18 @@ func (in *DeploymentSetSpec) DeepCopyInto(out *DeploymentSetSpec) {
 		*out = make([]SecretConfig, len(*in))
 		copy(*out, *in)
 	}
+	if in.Approved != nil {
+		in, out := &in.Approved, &out.Approved
+		*out = new(bool)
+		**out = **in
+	}
+	in.Hooks.DeepCopyInto(&out.Hooks)
+	if in.SmokeTest != nil {
+		in, out := &in.SmokeTest, &out.SmokeTest
+		*out = new(SmokeTestSpec)
+		**out = **in
+	}
+	in.Build.DeepCopyInto(&out.Build)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentSetSpec.
@@ -274,6 +452,13 @@ func (in *DeploymentSetSpec) DeepCopy() *DeploymentSetSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DeploymentSetStatus) DeepCopyInto(out *DeploymentSetStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentSetStatus.
@@ -333,6 +518,215 @@ func (in *EcrCredentials) DeepCopy() *EcrCredentials {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FreezePeriod) DeepCopyInto(out *FreezePeriod) {
+	*out = *in
+	in.Start.DeepCopyInto(&out.Start)
+	in.End.DeepCopyInto(&out.End)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FreezePeriod.
+func (in *FreezePeriod) DeepCopy() *FreezePeriod {
+	if in == nil {
+		return nil
+	}
+	out := new(FreezePeriod)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FreezePolicy) DeepCopyInto(out *FreezePolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FreezePolicy.
+func (in *FreezePolicy) DeepCopy() *FreezePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(FreezePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FreezePolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FreezePolicyList) DeepCopyInto(out *FreezePolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]FreezePolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FreezePolicyList.
+func (in *FreezePolicyList) DeepCopy() *FreezePolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(FreezePolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FreezePolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FreezePolicySpec) DeepCopyInto(out *FreezePolicySpec) {
+	*out = *in
+	if in.Windows != nil {
+		in, out := &in.Windows, &out.Windows
+		*out = make([]FreezeWindow, len(*in))
+		copy(*out, *in)
+	}
+	if in.Periods != nil {
+		in, out := &in.Periods, &out.Periods
+		*out = make([]FreezePeriod, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FreezePolicySpec.
+func (in *FreezePolicySpec) DeepCopy() *FreezePolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FreezePolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FreezePolicyStatus) DeepCopyInto(out *FreezePolicyStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FreezePolicyStatus.
+func (in *FreezePolicyStatus) DeepCopy() *FreezePolicyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FreezePolicyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FreezeWindow) DeepCopyInto(out *FreezeWindow) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FreezeWindow.
+func (in *FreezeWindow) DeepCopy() *FreezeWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(FreezeWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HookSpec) DeepCopyInto(out *HookSpec) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]corev1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.BackoffLimit != nil {
+		in, out := &in.BackoffLimit, &out.BackoffLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ActiveDeadlineSeconds != nil {
+		in, out := &in.ActiveDeadlineSeconds, &out.ActiveDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HookSpec.
+func (in *HookSpec) DeepCopy() *HookSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HookSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HooksSpec) DeepCopyInto(out *HooksSpec) {
+	*out = *in
+	if in.PreDeploy != nil {
+		in, out := &in.PreDeploy, &out.PreDeploy
+		*out = make([]HookSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PostDeploy != nil {
+		in, out := &in.PostDeploy, &out.PostDeploy
+		*out = make([]HookSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HooksSpec.
+func (in *HooksSpec) DeepCopy() *HooksSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HooksSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IngressYamlManifestType) DeepCopyInto(out *IngressYamlManifestType) {
 	*out = *in
@@ -350,6 +744,210 @@ func (in *IngressYamlManifestType) DeepCopy() *IngressYamlManifestType {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationFilter) DeepCopyInto(out *NotificationFilter) {
+	*out = *in
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationFilter.
+func (in *NotificationFilter) DeepCopy() *NotificationFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationHeader) DeepCopyInto(out *NotificationHeader) {
+	*out = *in
+	if in.ValueFrom != nil {
+		in, out := &in.ValueFrom, &out.ValueFrom
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationHeader.
+func (in *NotificationHeader) DeepCopy() *NotificationHeader {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationHeader)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationPolicy) DeepCopyInto(out *NotificationPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationPolicy.
+func (in *NotificationPolicy) DeepCopy() *NotificationPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NotificationPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationPolicyList) DeepCopyInto(out *NotificationPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]NotificationPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationPolicyList.
+func (in *NotificationPolicyList) DeepCopy() *NotificationPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NotificationPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationPolicySpec) DeepCopyInto(out *NotificationPolicySpec) {
+	*out = *in
+	if in.Sinks != nil {
+		in, out := &in.Sinks, &out.Sinks
+		*out = make([]NotificationSink, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationPolicySpec.
+func (in *NotificationPolicySpec) DeepCopy() *NotificationPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationPolicyStatus) DeepCopyInto(out *NotificationPolicyStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationPolicyStatus.
+func (in *NotificationPolicyStatus) DeepCopy() *NotificationPolicyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationPolicyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationSink) DeepCopyInto(out *NotificationSink) {
+	*out = *in
+	if in.URLFrom != nil {
+		in, out := &in.URLFrom, &out.URLFrom
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make([]NotificationHeader, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Filter.DeepCopyInto(&out.Filter)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationSink.
+func (in *NotificationSink) DeepCopy() *NotificationSink {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceDiff) DeepCopyInto(out *ResourceDiff) {
+	*out = *in
+	if in.Fields != nil {
+		in, out := &in.Fields, &out.Fields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AddedKeys != nil {
+		in, out := &in.AddedKeys, &out.AddedKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RemovedKeys != nil {
+		in, out := &in.RemovedKeys, &out.RemovedKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ChangedKeys != nil {
+		in, out := &in.ChangedKeys, &out.ChangedKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceDiff.
+func (in *ResourceDiff) DeepCopy() *ResourceDiff {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceDiff)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretConfig) DeepCopyInto(out *SecretConfig) {
 	*out = *in
@@ -381,3 +979,181 @@ func (in *ServiceYamlManifestType) DeepCopy() *ServiceYamlManifestType {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmokeTestSpec) DeepCopyInto(out *SmokeTestSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmokeTestSpec.
+func (in *SmokeTestSpec) DeepCopy() *SmokeTestSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SmokeTestSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmokeTestStatus) DeepCopyInto(out *SmokeTestStatus) {
+	*out = *in
+	if in.LastAttemptTime != nil {
+		in, out := &in.LastAttemptTime, &out.LastAttemptTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmokeTestStatus.
+func (in *SmokeTestStatus) DeepCopy() *SmokeTestStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SmokeTestStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookDelivery) DeepCopyInto(out *WebhookDelivery) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookDelivery.
+func (in *WebhookDelivery) DeepCopy() *WebhookDelivery {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookDelivery)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WebhookDelivery) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookDeliveryList) DeepCopyInto(out *WebhookDeliveryList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]WebhookDelivery, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookDeliveryList.
+func (in *WebhookDeliveryList) DeepCopy() *WebhookDeliveryList {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookDeliveryList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WebhookDeliveryList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookDeliverySink) DeepCopyInto(out *WebhookDeliverySink) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookDeliverySink.
+func (in *WebhookDeliverySink) DeepCopy() *WebhookDeliverySink {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookDeliverySink)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookDeliverySpec) DeepCopyInto(out *WebhookDeliverySpec) {
+	*out = *in
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Event != nil {
+		in, out := &in.Event, &out.Event
+		*out = new(WebhookEvent)
+		**out = **in
+	}
+	if in.Sink != nil {
+		in, out := &in.Sink, &out.Sink
+		*out = new(WebhookDeliverySink)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookDeliverySpec.
+func (in *WebhookDeliverySpec) DeepCopy() *WebhookDeliverySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookDeliverySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookDeliveryStatus) DeepCopyInto(out *WebhookDeliveryStatus) {
+	*out = *in
+	if in.LastAttemptTime != nil {
+		in, out := &in.LastAttemptTime, &out.LastAttemptTime
+		*out = (*in).DeepCopy()
+	}
+	if in.DeliveredTime != nil {
+		in, out := &in.DeliveredTime, &out.DeliveredTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookDeliveryStatus.
+func (in *WebhookDeliveryStatus) DeepCopy() *WebhookDeliveryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookDeliveryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookEvent) DeepCopyInto(out *WebhookEvent) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookEvent.
+func (in *WebhookEvent) DeepCopy() *WebhookEvent {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookEvent)
+	in.DeepCopyInto(out)
+	return out
+}
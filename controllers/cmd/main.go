@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
 	"os"
@@ -36,6 +37,8 @@ import (
 
 	k8sv1 "github.com/Humalect/humalect-core/api/v1"
 	"github.com/Humalect/humalect-core/internal/controller"
+	helpers "github.com/Humalect/humalect-core/internal/controller/helpers"
+	"github.com/Humalect/humalect-core/pkg/tracing"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -98,19 +101,29 @@ func main() {
 	}
 
 	if err = (&controller.ApplicationReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("application-controller"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Application")
 		os.Exit(1)
 	}
 	if err = (&controller.DeploymentSetReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("deploymentset-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "DeploymentSet")
+		os.Exit(1)
+	}
+	if err = (&controller.WebhookDeliveryReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "DeploymentSet")
+		setupLog.Error(err, "unable to create controller", "controller", "WebhookDelivery")
 		os.Exit(1)
 	}
+	helpers.SetWebhookDeliveryClient(mgr.GetClient())
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -122,8 +135,20 @@ func main() {
 		os.Exit(1)
 	}
 
+	ctx := ctrl.SetupSignalHandler()
+	shutdownTracing, err := tracing.Setup(ctx, "humalect-controller")
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	err = mgr.Start(ctx)
+	// Flush the spans of deployments that were in progress, the signal context is already cancelled here.
+	if err := shutdownTracing(context.Background()); err != nil {
+		setupLog.Error(err, "problem flushing traces")
+	}
+	if err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
@@ -19,8 +19,10 @@ package controller
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -28,6 +30,8 @@ import (
 	k8sv1 "github.com/Humalect/humalect-core/api/v1"
 	constants "github.com/Humalect/humalect-core/internal/controller/constants"
 	helpers "github.com/Humalect/humalect-core/internal/controller/helpers"
+	"github.com/Humalect/humalect-core/pkg/tracing"
+	"github.com/Humalect/humalect-core/pkg/webhook"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -36,7 +40,8 @@ import (
 // ApplicationReconciler reconciles a Application object
 type ApplicationReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
 }
 
 //+kubebuilder:rbac:groups=k8s.humalect.com,resources=applications,verbs=get;list;watch;create;update;patch;delete
@@ -80,6 +85,7 @@ func (r *ApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 		return ctrl.Result{}, err
 	}
+	ctx = helpers.ContextWithRedactor(ctx, helpers.GetApplicationRedactor(application))
 
 	fmt.Print("application.DeletionTimestamp: ", application.DeletionTimestamp)
 	fmt.Print("application.DeletionTimestamp.IsZero(): ", application.DeletionTimestamp.IsZero())
@@ -88,19 +94,83 @@ func (r *ApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	if !application.DeletionTimestamp.IsZero() && containsString(application.ObjectMeta.Finalizers, applicationFinalizerName) {
 		fmt.Print("Deleting Application------------------ \n")
 		return r.handleDeletion(ctx, application)
+	}
+
+	ctx = tracing.ContextWithTraceParent(ctx, application.Spec.TraceParent)
+	application.Spec.WebhookData = helpers.UpdateWebhookDataId(application.Spec.WebhookData, "deploymentId", application.Spec.DeploymentId)
+	application.Spec.WebhookData = helpers.UpdateWebhookDataField(application.Spec.WebhookData, "pipelineId", application.Spec.PipelineId)
+	application.Spec.WebhookData = helpers.UpdateWebhookDataField(application.Spec.WebhookData, "namespace", application.GetNamespace())
+	application.Spec.WebhookData = helpers.UpdateWebhookDataField(application.Spec.WebhookData, "managedBy", application.Spec.ManagedBy)
+	application.Spec.WebhookData = helpers.UpdateWebhookDataFormat(application.Spec.WebhookData, application.Spec.WebhookFormat, application.Spec.WebhookContentMode)
+	if application.Spec.ApplyMode == constants.ApplyModePlan {
+		return r.handlePlan(ctx, application)
 	} else {
+		// A freeze only holds back a rollout that has not started, generations already rolled out are left alone.
+		if application.Status.ObservedGeneration != application.Generation {
+			if frozen, res, err := r.holdForDeploymentFreeze(ctx, application); frozen {
+				return res, err
+			}
+		}
+		if isDeployStopped(application) {
+			log.Info(fmt.Sprintf("log for <depid:%s> <pipeid:%s> Application deploy failed or was rolled back, skipping until the spec changes", application.Spec.DeploymentId, application.Spec.PipelineId))
+			return ctrl.Result{}, nil
+		}
+		start := time.Now()
 		res, err := r.handleCreation(ctx, application, application.Spec.DeploymentYamlManifest, application.Spec.ServiceYamlManifest, application.Spec.IngressYamlManifest, application.Spec.Namespace)
 		if err != nil {
-			application.Spec.WebhookData = helpers.UpdateStatusData(application.Spec.WebhookData, constants.CreatedKubernetesResources, false)
-			helpers.SendWebhook(application.Spec.WebhookEndpoint, application.Spec.WebhookData, false, constants.CreatedKubernetesResources)
+			// Only a stopped deploy ends with this failure, other errors are retried.
+			stopped := isDeployStopped(application)
+			r.sendWebhook(ctx, application, constants.CreatedKubernetesResources, false, helpers.WebhookStepDetails{Error: err.Error(), Reason: "ApplyFailed", DurationSeconds: webhook.SecondsSince(start), Terminal: stopped})
+			if stopped {
+				// The failure is recorded in a condition, requeueing would only repeat it.
+				return ctrl.Result{}, nil
+			}
+			return res, err
+		}
+		if res.RequeueAfter > 0 {
+			return res, nil
 		}
 		application.Spec.WebhookData = helpers.UpdateStatusData(application.Spec.WebhookData, constants.CreatedKubernetesResources, true)
-		application.Spec.WebhookData = helpers.UpdateStatusData(application.Spec.WebhookData, constants.DeploymentCompleted, true)
-		helpers.SendWebhook(application.Spec.WebhookEndpoint, application.Spec.WebhookData, true, constants.DeploymentCompleted)
+		if application.Status.ObservedGeneration != application.Generation {
+			application.Status.ObservedGeneration = application.Generation
+			if err := r.updateStatus(ctx, application); err != nil {
+				return res, err
+			}
+		}
+		r.sendWebhook(ctx, application, constants.DeploymentCompleted, true, helpers.WebhookStepDetails{DurationSeconds: webhook.SecondsSince(start), Terminal: true})
 		return res, err
 	}
 }
 
+// updateStatus writes the status while keeping the in-memory webhook data, which collects the outcome
+// of the steps of this reconcile and is never persisted.
+func (r *ApplicationReconciler) updateStatus(ctx context.Context, application *k8sv1.Application) error {
+	webhookData := application.Spec.WebhookData
+	err := r.Status().Update(ctx, application)
+	application.Spec.WebhookData = webhookData
+	return err
+}
+
+// sendWebhook adds the step outcome to the webhook data and sends it, unless the same outcome was already
+// delivered for this deployment. Delivered outcomes are kept in status so resyncs and restarts don't repeat them.
+func (r *ApplicationReconciler) sendWebhook(ctx context.Context, application *k8sv1.Application, step string, success bool, details helpers.WebhookStepDetails) {
+	log := log.FromContext(ctx)
+
+	application.Spec.WebhookData = helpers.UpdateStatusData(application.Spec.WebhookData, step, success)
+	if helpers.IsNotificationDelivered(application.Status.Notifications, application.Spec.DeploymentId, step, success) {
+		if details.Terminal {
+			// The failure was sent before the deploy stopped, only the outcome is still to be counted.
+			helpers.RecordDeploymentMetrics(application.Spec.WebhookData, success, step, details)
+		}
+		return
+	}
+	helpers.SendWebhookWithDetails(application.Spec.WebhookEndpoint, application.Spec.WebhookData, success, step, helpers.RedactDetails(ctx, details))
+	application.Status.Notifications = helpers.SetNotificationDelivered(application.Status.Notifications, application.Spec.DeploymentId, step, success, time.Now())
+	if err := r.updateStatus(ctx, application); err != nil {
+		log.Error(err, fmt.Sprintf("log for <depid:%s> <pipeid:%s> ERROR: Failed to record delivered notification %s, %v", application.Spec.DeploymentId, application.Spec.PipelineId, step, err))
+	}
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *ApplicationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
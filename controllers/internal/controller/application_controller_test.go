@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	k8sv1 "github.com/Humalect/humalect-core/api/v1"
+	constants "github.com/Humalect/humalect-core/internal/controller/constants"
+	helpers "github.com/Humalect/humalect-core/internal/controller/helpers"
+)
+
+func TestApplicationSendWebhookPerDeployment(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := k8sv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	application := &k8sv1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team-a", Generation: 1},
+		Spec:       k8sv1.ApplicationSpec{WebhookData: "{}"},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(application).WithStatusSubresource(application).Build()
+	helpers.SetWebhookDeliveryClient(c)
+	defer helpers.SetWebhookDeliveryClient(nil)
+	r := &ApplicationReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10)}
+
+	// A resync of the first deployment must not send its notification again, the next deployment must.
+	ctx := context.Background()
+	for _, deploymentId := range []string{"dep-1", "dep-1", "dep-2"} {
+		application.Spec.DeploymentId = deploymentId
+		application.Spec.WebhookData = helpers.UpdateWebhookDataId(application.Spec.WebhookData, "deploymentId", deploymentId)
+		r.sendWebhook(ctx, application, constants.DeploymentCompleted, true, helpers.WebhookStepDetails{})
+	}
+
+	deliveries := &k8sv1.WebhookDeliveryList{}
+	if err := c.List(ctx, deliveries); err != nil {
+		t.Fatal(err)
+	}
+	sent := map[string]int{}
+	for _, delivery := range deliveries.Items {
+		sent[delivery.Spec.DeploymentId]++
+	}
+	if len(deliveries.Items) != 2 || sent["dep-1"] != 1 || sent["dep-2"] != 1 {
+		t.Fatalf("sent notifications = %v, want one for each deployment", sent)
+	}
+}
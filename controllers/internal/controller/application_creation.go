@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
@@ -12,14 +13,159 @@ import (
 	constants "github.com/Humalect/humalect-core/internal/controller/constants"
 	helpers "github.com/Humalect/humalect-core/internal/controller/helpers"
 	cloudhelpers "github.com/Humalect/humalect-core/internal/controller/helpers/cloud"
+	"github.com/Humalect/humalect-core/internal/controller/metrics"
+	"github.com/Humalect/humalect-core/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
-func (r *ApplicationReconciler) handleCreation(ctx context.Context, application *k8sv1.Application, DeploymentYamlManifest k8sv1.DeploymentYamlManifestType, ServiceYamlManifest k8sv1.ServiceYamlManifestType, IngressYamlManifest k8sv1.IngressYamlManifestType, Namespace string) (ctrl.Result, error) {
+func (r *ApplicationReconciler) handleCreation(ctx context.Context, application *k8sv1.Application, DeploymentYamlManifest k8sv1.DeploymentYamlManifestType, ServiceYamlManifest k8sv1.ServiceYamlManifestType, IngressYamlManifest k8sv1.IngressYamlManifestType, Namespace string) (res ctrl.Result, err error) {
+	ctx, span := tracing.StartSpan(ctx, "application.apply", trace.WithAttributes(
+		attribute.String("humalect.deployment_id", application.Spec.DeploymentId),
+		attribute.String("humalect.application", application.GetName()),
+		attribute.String("humalect.namespace", application.GetNamespace()),
+	))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	objects := r.getApplicationObjects(ctx, application, DeploymentYamlManifest, ServiceYamlManifest, IngressYamlManifest, Namespace)
+	if err := tracing.RunInSpan(ctx, "compute-diff", func(ctx context.Context) error {
+		return r.updateApplicationDiff(ctx, application, constants.ApplicationPhaseApplied, objects...)
+	}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// The hooks, the rollout and the smoke test only run until a generation is verified, resyncs of a
+	// verified generation only apply the resources again.
+	verified := application.Status.ObservedGeneration == application.Generation
+	var finished bool
+	if !verified {
+		err = tracing.RunInSpan(ctx, "pre-deploy-hooks", func(ctx context.Context) (err error) {
+			finished, err = r.runDeployHooks(ctx, application, application.Spec.Hooks.PreDeploy, hookPhasePreDeploy, constants.PreDeployHookExecuted)
+			return err
+		})
+		if err != nil && finished {
+			return ctrl.Result{}, r.stopDeployment(ctx, application, "PreDeployHookFailed", err)
+		}
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !finished {
+			return requeueDeployProgress(), nil
+		}
+	}
+
+	err = tracing.RunInSpan(ctx, "apply-resources", func(ctx context.Context) error {
+		res, err = helpers.CreateK8sResource(ctx, application, application.GetNamespace(), (*helpers.ApplicationReconciler)(r), objects...)
+		return err
+	})
+	if err != nil || verified || (len(application.Spec.Hooks.PostDeploy) == 0 && application.Spec.SmokeTest == nil) {
+		return res, err
+	}
+
+	if !isConditionTrueForGeneration(application, constants.ConditionTypeRolledOut) {
+		var rolledOut bool
+		err = tracing.RunInSpan(ctx, "check-rollout", func(ctx context.Context) (err error) {
+			rolledOut, err = r.checkRollout(ctx, application)
+			return err
+		})
+		if err != nil && rolledOut {
+			return res, r.handlePostDeployFailure(ctx, application, "RolloutFailed", err)
+		}
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !rolledOut {
+			return requeueDeployProgress(), nil
+		}
+	}
+
+	err = tracing.RunInSpan(ctx, "post-deploy-hooks", func(ctx context.Context) (err error) {
+		finished, err = r.runDeployHooks(ctx, application, application.Spec.Hooks.PostDeploy, hookPhasePostDeploy, constants.PostDeployHookExecuted)
+		return err
+	})
+	if err != nil && finished {
+		return res, r.handlePostDeployFailure(ctx, application, "PostDeployHookFailed", err)
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !finished {
+		return requeueDeployProgress(), nil
+	}
+	if application.Spec.SmokeTest != nil {
+		var retryAfter time.Duration
+		err = tracing.RunInSpan(ctx, "smoke-test", func(ctx context.Context) (err error) {
+			finished, retryAfter, err = r.runSmokeTest(ctx, application)
+			return err
+		})
+		if err != nil && finished {
+			return res, r.handlePostDeployFailure(ctx, application, "SmokeTestFailed", err)
+		}
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !finished {
+			return ctrl.Result{RequeueAfter: retryAfter}, nil
+		}
+	}
+	return res, nil
+}
+
+// requeueDeployProgress checks on a running hook Job or rollout again later, reconciles never wait for them.
+// Besides the retry of a smoke test it is the only result of handleCreation with RequeueAfter set.
+func requeueDeployProgress() ctrl.Result {
+	return ctrl.Result{RequeueAfter: constants.DeployProgressRequeueSeconds * time.Second}
+}
+
+// checkRollout reports whether the rollout of the Deployment completed or failed, the error is then the
+// failure of the rollout. A completed rollout is recorded in the RolledOut condition.
+func (r *ApplicationReconciler) checkRollout(ctx context.Context, application *k8sv1.Application) (bool, error) {
+	log := log.FromContext(ctx)
+
+	deployment, err := r.getLiveDeployment(ctx, application)
+	if err != nil || deployment == nil {
+		return false, err
+	}
+	rolledOut, failure := helpers.IsDeploymentRolledOut(deployment)
+	if failure != nil {
+		helpers.RecordEvent(ctx, r.Recorder, application, corev1.EventTypeWarning, "RolloutFailed", "Rollout of deployment %s failed: %v", deployment.GetName(), failure)
+		return true, failure
+	}
+	if !rolledOut {
+		log.Info(fmt.Sprintf("log for <depid:%s> <pipeid:%s> Waiting for rollout of deployment %s", application.Spec.DeploymentId, application.Spec.PipelineId, deployment.GetName()))
+		if meta.IsStatusConditionTrue(application.Status.Conditions, constants.ConditionTypeRolledOut) {
+			meta.SetStatusCondition(&application.Status.Conditions, metav1.Condition{
+				Type:               constants.ConditionTypeRolledOut,
+				Status:             metav1.ConditionFalse,
+				Reason:             "RolloutInProgress",
+				Message:            fmt.Sprintf("Waiting for rollout of deployment %s", deployment.GetName()),
+				ObservedGeneration: application.Generation,
+			})
+			return false, r.updateStatus(ctx, application)
+		}
+		return false, nil
+	}
+	helpers.RecordEvent(ctx, r.Recorder, application, corev1.EventTypeNormal, "RolloutComplete", "Rollout of deployment %s completed", deployment.GetName())
+	meta.SetStatusCondition(&application.Status.Conditions, metav1.Condition{
+		Type:               constants.ConditionTypeRolledOut,
+		Status:             metav1.ConditionTrue,
+		Reason:             "RolloutComplete",
+		Message:            fmt.Sprintf("Every replica of deployment %s runs the latest template", deployment.GetName()),
+		ObservedGeneration: application.Generation,
+	})
+	if err := r.updateStatus(ctx, application); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *ApplicationReconciler) getApplicationObjects(ctx context.Context, application *k8sv1.Application, DeploymentYamlManifest k8sv1.DeploymentYamlManifestType, ServiceYamlManifest k8sv1.ServiceYamlManifestType, IngressYamlManifest k8sv1.IngressYamlManifestType, Namespace string) []helpers.Object {
 	// Create a slice of Object to store the objects you want to pass
 	log := log.FromContext(ctx)
 
@@ -55,12 +201,15 @@ func (r *ApplicationReconciler) handleCreation(ctx context.Context, application
 				},
 				Namespace: Namespace,
 			}
+			fetchStart := time.Now()
 			SecretStringData, err := cloudhelpers.GetCloudSecretMap(application, secretConfig)
+			metrics.RecordSecretFetch(application.GetNamespace(), application.Spec.ManagedBy, cloudhelpers.GetSecretsProvider(application), fetchStart, err)
 			if err != nil {
 				log.Error(err, fmt.Sprintf("log for <depid:%s> <pipeid:%s> ERROR: Failed to get cloud Secret Data, %v", application.Spec.DeploymentId, application.Spec.PipelineId, err))
-				application.Spec.WebhookData = helpers.UpdateStatusData(application.Spec.WebhookData, constants.CreatedKubernetesResources, false)
-				helpers.SendWebhook(application.Spec.WebhookEndpoint, application.Spec.WebhookData, false, constants.CreatedKubernetesResources)
+				helpers.RecordEvent(ctx, r.Recorder, application, corev1.EventTypeWarning, "SecretFetchFailed", "Failed to fetch secret %s: %v", secretConfig.Name, err)
+				r.sendWebhook(ctx, application, constants.CreatedKubernetesResources, false, helpers.WebhookStepDetails{Error: err.Error(), Reason: "SecretFetchFailed"})
 			} else {
+				helpers.RedactorFromContext(ctx).AddSecretData(SecretStringData)
 				objects = append(objects, &corev1.Secret{
 					ObjectMeta: secretMetadataObject,
 					StringData: SecretStringData,
@@ -71,5 +220,5 @@ func (r *ApplicationReconciler) handleCreation(ctx context.Context, application
 		}
 	}
 
-	return helpers.CreateK8sResource(ctx, application, application.GetNamespace(), (*helpers.ApplicationReconciler)(r), objects...)
+	return objects
 }
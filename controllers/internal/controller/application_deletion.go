@@ -8,6 +8,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	k8sv1 "github.com/Humalect/humalect-core/api/v1"
+	corev1 "k8s.io/api/core/v1"
 )
 
 func (r *ApplicationReconciler) handleDeletion(ctx context.Context, application *k8sv1.Application) (ctrl.Result, error) {
@@ -44,6 +45,7 @@ func (r *ApplicationReconciler) handleDeletion(ctx context.Context, application
 	if containsString(application.ObjectMeta.Finalizers, finalizerName) {
 		log := log.FromContext(ctx)
 
+		r.Recorder.Event(application, corev1.EventTypeNormal, "Deleting", "Application is being deleted")
 		application.ObjectMeta.Finalizers = removeString(application.ObjectMeta.Finalizers, finalizerName)
 		if err := r.Update(ctx, application); err != nil {
 			log.Error(err, fmt.Sprintf("log for <depid:%s> <pipeid:%s> ERROR: Failed to get Application, %v", application.Spec.DeploymentId, application.Spec.PipelineId, err))
@@ -0,0 +1,303 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	k8sv1 "github.com/Humalect/humalect-core/api/v1"
+	constants "github.com/Humalect/humalect-core/internal/controller/constants"
+	helpers "github.com/Humalect/humalect-core/internal/controller/helpers"
+)
+
+const (
+	hookPhasePreDeploy           = "pre"
+	hookPhasePostDeploy          = "post"
+	deploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+)
+
+// runDeployHooks starts the hooks one after the other without waiting for them. It reports whether every
+// hook completed or one of them failed, the error of a finished run is the failure of the hook. While a hook
+// Job runs the reconcile is requeued. Hook Jobs are named after the deployment id, so a hook that already
+// ran for this deployment is not started again.
+func (r *ApplicationReconciler) runDeployHooks(ctx context.Context, application *k8sv1.Application, hooks []k8sv1.HookSpec, phase string, step string) (bool, error) {
+	log := log.FromContext(ctx)
+
+	for _, hook := range hooks {
+		job, err := r.getOrCreateHookJob(ctx, application, hook, phase)
+		if err != nil {
+			return false, err
+		}
+		finished, failure := helpers.IsJobFinished(job)
+		if !finished {
+			log.Info(fmt.Sprintf("log for <depid:%s> <pipeid:%s> Waiting for %s-deploy hook %s", application.Spec.DeploymentId, application.Spec.PipelineId, phase, hook.Name))
+			return false, nil
+		}
+
+		// Later reconciles find the finished Job again, its outcome is only reported once.
+		hookStep := fmt.Sprintf("%s:%s", step, hook.Name)
+		if !helpers.IsNotificationDelivered(application.Status.Notifications, application.Spec.DeploymentId, hookStep, failure == nil) {
+			details := helpers.WebhookStepDetails{DurationSeconds: helpers.GetJobDurationSeconds(job)}
+			if failure != nil {
+				details.Error = failure.Error()
+				details.Reason = "HookFailed"
+				log.Error(failure, fmt.Sprintf("log for <depid:%s> <pipeid:%s> ERROR: %s-deploy hook %s failed, %v", application.Spec.DeploymentId, application.Spec.PipelineId, phase, hook.Name, failure))
+				helpers.RecordEvent(ctx, r.Recorder, application, corev1.EventTypeWarning, "HookFailed", "%s-deploy hook %s failed: %v", phase, hook.Name, failure)
+			} else {
+				log.Info(fmt.Sprintf("log for <depid:%s> <pipeid:%s> %s-deploy hook %s completed", application.Spec.DeploymentId, application.Spec.PipelineId, phase, hook.Name))
+				helpers.RecordEvent(ctx, r.Recorder, application, corev1.EventTypeNormal, "HookCompleted", "%s-deploy hook %s completed", phase, hook.Name)
+			}
+			r.sendWebhook(ctx, application, hookStep, failure == nil, details)
+		}
+		if failure != nil {
+			return true, failure
+		}
+	}
+	return true, nil
+}
+
+func (r *ApplicationReconciler) getOrCreateHookJob(ctx context.Context, application *k8sv1.Application, hook k8sv1.HookSpec, phase string) (*batchv1.Job, error) {
+	job := getHookJobObject(application, hook, phase)
+	liveJob := &batchv1.Job{}
+	err := r.Get(ctx, client.ObjectKey{Name: job.GetName(), Namespace: job.GetNamespace()}, liveJob)
+	if err == nil {
+		return liveJob, nil
+	}
+	if !errors.IsNotFound(err) {
+		return nil, err
+	}
+	controllerRef := metav1.NewControllerRef(application, k8sv1.GroupVersion.WithKind("Application"))
+	job.SetOwnerReferences(append(job.GetOwnerReferences(), *controllerRef))
+	if err := r.Create(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// getHookJobName names the hook Job after the generation, so the hooks run again for every spec change even
+// when the deployment id is missing or reused. The name is cut from the front to keep this suffix.
+func getHookJobName(application *k8sv1.Application, hook k8sv1.HookSpec, phase string) string {
+	regex := regexp.MustCompile("[^a-z0-9-]+")
+	suffix := fmt.Sprintf("g%d", application.Generation)
+	if deploymentId := application.Spec.DeploymentId; deploymentId != "" {
+		if len(deploymentId) > 7 {
+			deploymentId = deploymentId[:7]
+		}
+		suffix = fmt.Sprintf("%s-%s", deploymentId, suffix)
+	}
+	name := regex.ReplaceAllString(strings.ToLower(fmt.Sprintf("%s-%s-%s-%s", application.GetName(), phase, hook.Name, suffix)), "-")
+	if len(name) > 63 {
+		name = name[len(name)-63:]
+	}
+	return strings.Trim(name, "-")
+}
+
+// getHookJobObject builds the Job for a hook. It defaults to the image, env and pull secrets of the
+// application container so hooks like migrations run against the freshly built image.
+func getHookJobObject(application *k8sv1.Application, hook k8sv1.HookSpec, phase string) *batchv1.Job {
+	podSpec := application.Spec.DeploymentYamlManifest.Spec.Template.Spec
+	container := corev1.Container{
+		Name:    "hook",
+		Image:   hook.Image,
+		Command: hook.Command,
+		Args:    hook.Args,
+	}
+	if len(podSpec.Containers) > 0 {
+		if container.Image == "" {
+			container.Image = podSpec.Containers[0].Image
+		}
+		container.Env = append(container.Env, podSpec.Containers[0].Env...)
+		container.EnvFrom = podSpec.Containers[0].EnvFrom
+	}
+	container.Env = append(container.Env, hook.Env...)
+
+	backoffLimit := int32(0)
+	if hook.BackoffLimit != nil {
+		backoffLimit = *hook.BackoffLimit
+	}
+	// The Job fails on its own once the deadline passed, nothing waits for it.
+	activeDeadlineSeconds := int64(constants.DefaultHookTimeoutSeconds)
+	if hook.ActiveDeadlineSeconds != nil {
+		activeDeadlineSeconds = *hook.ActiveDeadlineSeconds
+	}
+	labels := map[string]string{
+		"managedBy":    application.Spec.ManagedBy,
+		"identifier":   application.Spec.K8sResourcesIdentifier,
+		"deploymentId": application.Spec.DeploymentId,
+		"pipelineId":   application.Spec.PipelineId,
+		"partOf":       "client-application",
+		"resourceType": fmt.Sprintf("client-application-%s-deploy-hook", phase),
+	}
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getHookJobName(application, hook, phase),
+			Namespace: application.GetNamespace(),
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          &backoffLimit,
+			ActiveDeadlineSeconds: &activeDeadlineSeconds,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers:         []corev1.Container{container},
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ImagePullSecrets:   podSpec.ImagePullSecrets,
+					ServiceAccountName: podSpec.ServiceAccountName,
+				},
+			},
+		},
+	}
+}
+
+func (r *ApplicationReconciler) getLiveDeployment(ctx context.Context, application *k8sv1.Application) (*appsv1.Deployment, error) {
+	deployment := &appsv1.Deployment{}
+	err := r.Get(ctx, client.ObjectKey{Name: application.Spec.DeploymentYamlManifest.Metadata.Name, Namespace: application.GetNamespace()}, deployment)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return deployment, nil
+}
+
+// getPreviousRevisionTemplate returns the template of the revision the Deployment ran before the current one,
+// read from its ReplicaSets like kubectl rollout undo does, or nil when there is no earlier revision.
+func (r *ApplicationReconciler) getPreviousRevisionTemplate(ctx context.Context, deployment *appsv1.Deployment) (*corev1.PodTemplateSpec, error) {
+	currentRevision, err := strconv.ParseInt(deployment.Annotations[deploymentRevisionAnnotation], 10, 64)
+	if err != nil {
+		return nil, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	replicaSets := &appsv1.ReplicaSetList{}
+	if err := r.List(ctx, replicaSets, client.InNamespace(deployment.GetNamespace()), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+	var previous *appsv1.ReplicaSet
+	previousRevision := int64(0)
+	for i := range replicaSets.Items {
+		replicaSet := &replicaSets.Items[i]
+		if !metav1.IsControlledBy(replicaSet, deployment) {
+			continue
+		}
+		revision, err := strconv.ParseInt(replicaSet.Annotations[deploymentRevisionAnnotation], 10, 64)
+		if err != nil || revision >= currentRevision || revision <= previousRevision {
+			continue
+		}
+		previous, previousRevision = replicaSet, revision
+	}
+	if previous == nil {
+		return nil, nil
+	}
+	template := previous.Spec.Template.DeepCopy()
+	delete(template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+	return template, nil
+}
+
+// handlePostDeployFailure applies the hooks failure policy once the rollout or a post-deploy step failed.
+func (r *ApplicationReconciler) handlePostDeployFailure(ctx context.Context, application *k8sv1.Application, reason string, failure error) error {
+	switch application.Spec.Hooks.FailurePolicy {
+	case constants.HookFailurePolicyIgnore:
+		return nil
+	case constants.HookFailurePolicyRollback:
+		rolledBack, err := r.rollbackDeployment(ctx, application, failure)
+		if err != nil {
+			return err
+		}
+		if !rolledBack {
+			return r.stopDeployment(ctx, application, reason, failure)
+		}
+		return failure
+	default:
+		return r.stopDeployment(ctx, application, reason, failure)
+	}
+}
+
+// rollbackDeployment puts the previous revision of the Deployment back. It reports false when the Deployment
+// has no earlier revision, a first deploy is left as it is.
+func (r *ApplicationReconciler) rollbackDeployment(ctx context.Context, application *k8sv1.Application, failure error) (bool, error) {
+	log := log.FromContext(ctx)
+
+	deployment, err := r.getLiveDeployment(ctx, application)
+	if err != nil || deployment == nil {
+		return false, fmt.Errorf("failed to get deployment to roll back: %v", err)
+	}
+	previous, err := r.getPreviousRevisionTemplate(ctx, deployment)
+	if err != nil {
+		return false, err
+	}
+	if previous == nil {
+		log.Info(fmt.Sprintf("log for <depid:%s> <pipeid:%s> Deployment %s has no earlier revision to roll back to", application.Spec.DeploymentId, application.Spec.PipelineId, deployment.GetName()))
+		helpers.RecordEvent(ctx, r.Recorder, application, corev1.EventTypeWarning, "RollbackSkipped", "Deployment %s has no earlier revision to roll back to", deployment.GetName())
+		return false, nil
+	}
+	deployment.Spec.Template = *previous
+	if err := r.Update(ctx, deployment); err != nil {
+		log.Error(err, fmt.Sprintf("log for <depid:%s> <pipeid:%s> ERROR: Failed to roll back deployment, %v", application.Spec.DeploymentId, application.Spec.PipelineId, err))
+		helpers.RecordEvent(ctx, r.Recorder, application, corev1.EventTypeWarning, "RollbackFailed", "Failed to roll back deployment %s: %v", deployment.GetName(), err)
+		r.sendWebhook(ctx, application, constants.DeploymentRolledBack, false, helpers.WebhookStepDetails{Error: err.Error(), Reason: "RollbackFailed"})
+		return false, err
+	}
+	log.Info(fmt.Sprintf("log for <depid:%s> <pipeid:%s> Rolled back deployment %s", application.Spec.DeploymentId, application.Spec.PipelineId, deployment.GetName()))
+	helpers.RecordEvent(ctx, r.Recorder, application, corev1.EventTypeWarning, "RolledBack", "Rolled back deployment %s after a failed post-deploy step: %v", deployment.GetName(), failure)
+
+	meta.SetStatusCondition(&application.Status.Conditions, metav1.Condition{
+		Type:               constants.ConditionTypeRolledBack,
+		Status:             metav1.ConditionTrue,
+		Reason:             "PostDeployFailed",
+		Message:            helpers.RedactorFromContext(ctx).String(failure.Error()),
+		ObservedGeneration: application.Generation,
+	})
+	if err := r.updateStatus(ctx, application); err != nil {
+		return false, err
+	}
+	r.sendWebhook(ctx, application, constants.DeploymentRolledBack, true, helpers.WebhookStepDetails{Error: failure.Error(), Reason: "PostDeployFailed"})
+	return true, nil
+}
+
+// stopDeployment records the failure in the DeployFailed condition, so later reconciles of the same generation
+// do not run the failed step again, and returns the failure.
+func (r *ApplicationReconciler) stopDeployment(ctx context.Context, application *k8sv1.Application, reason string, failure error) error {
+	meta.SetStatusCondition(&application.Status.Conditions, metav1.Condition{
+		Type:               constants.ConditionTypeDeployFailed,
+		Status:             metav1.ConditionTrue,
+		Reason:             reason,
+		Message:            helpers.RedactorFromContext(ctx).String(failure.Error()),
+		ObservedGeneration: application.Generation,
+	})
+	if err := r.updateStatus(ctx, application); err != nil {
+		return err
+	}
+	return failure
+}
+
+// isDeployStopped reports whether the current generation failed or was rolled back. It is not deployed
+// again until the spec changes.
+func isDeployStopped(application *k8sv1.Application) bool {
+	for _, conditionType := range []string{constants.ConditionTypeRolledBack, constants.ConditionTypeDeployFailed} {
+		if isConditionTrueForGeneration(application, conditionType) {
+			return true
+		}
+	}
+	return false
+}
+
+func isConditionTrueForGeneration(application *k8sv1.Application, conditionType string) bool {
+	condition := meta.FindStatusCondition(application.Status.Conditions, conditionType)
+	return condition != nil && condition.Status == metav1.ConditionTrue && condition.ObservedGeneration == application.Generation
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	k8sv1 "github.com/Humalect/humalect-core/api/v1"
+	constants "github.com/Humalect/humalect-core/internal/controller/constants"
+	helpers "github.com/Humalect/humalect-core/internal/controller/helpers"
+)
+
+// handlePlan computes the diff between the live and the desired resources without applying
+// anything, so that it can be reviewed before switching the Application to apply mode.
+func (r *ApplicationReconciler) handlePlan(ctx context.Context, application *k8sv1.Application) (ctrl.Result, error) {
+	objects := r.getApplicationObjects(ctx, application, application.Spec.DeploymentYamlManifest, application.Spec.ServiceYamlManifest, application.Spec.IngressYamlManifest, application.Spec.Namespace)
+	if err := r.updateApplicationDiff(ctx, application, constants.ApplicationPhasePlanned, objects...); err != nil {
+		r.sendWebhook(ctx, application, constants.DeploymentPlanned, false, helpers.WebhookStepDetails{Error: err.Error(), Reason: "DiffFailed"})
+		return ctrl.Result{}, err
+	}
+	r.sendWebhook(ctx, application, constants.DeploymentPlanned, true, helpers.WebhookStepDetails{})
+	return ctrl.Result{}, nil
+}
+
+func (r *ApplicationReconciler) updateApplicationDiff(ctx context.Context, application *k8sv1.Application, phase string, objects ...helpers.Object) error {
+	log := log.FromContext(ctx)
+
+	diff, err := helpers.ComputeK8sResourceDiff(ctx, application.GetNamespace(), (*helpers.ApplicationReconciler)(r), objects...)
+	if err != nil {
+		log.Error(err, fmt.Sprintf("log for <depid:%s> <pipeid:%s> ERROR: Failed to compute diff, %v", application.Spec.DeploymentId, application.Spec.PipelineId, err))
+		return err
+	}
+	application.Spec.WebhookData = helpers.UpdateWebhookDataField(application.Spec.WebhookData, "diff", diff)
+
+	if application.Status.Phase == phase && equality.Semantic.DeepEqual(application.Status.Diff, diff) {
+		return nil
+	}
+	application.Status.Phase = phase
+	application.Status.Diff = diff
+	if err := r.updateStatus(ctx, application); err != nil {
+		log.Error(err, fmt.Sprintf("log for <depid:%s> <pipeid:%s> ERROR: Failed to update Application status, %v", application.Spec.DeploymentId, application.Spec.PipelineId, err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	k8sv1 "github.com/Humalect/humalect-core/api/v1"
+	constants "github.com/Humalect/humalect-core/internal/controller/constants"
+	helpers "github.com/Humalect/humalect-core/internal/controller/helpers"
+	"github.com/Humalect/humalect-core/pkg/webhook"
+)
+
+// runSmokeTest runs one attempt of the smoke test and reports whether the smoke test finished. A failed attempt
+// with retries left is recorded in status and retried after the returned delay, so no reconcile waits for it.
+// The outcome is recorded as a condition and a webhook step.
+func (r *ApplicationReconciler) runSmokeTest(ctx context.Context, application *k8sv1.Application) (bool, time.Duration, error) {
+	log := log.FromContext(ctx)
+
+	if isConditionTrueForGeneration(application, constants.ConditionTypeSmokeTestPassed) {
+		return true, 0, nil
+	}
+	smokeTest := *application.Spec.SmokeTest
+	status, wait := helpers.GetSmokeTestWait(application.Status.SmokeTest, application.Generation, smokeTest, time.Now())
+	if wait > 0 {
+		return false, wait, nil
+	}
+
+	start := time.Now()
+	url, err := helpers.GetSmokeTestURL(application, smokeTest)
+	retry := false
+	if err == nil {
+		err = helpers.RunSmokeTest(ctx, url, smokeTest)
+		retry = err != nil
+	}
+	status.Attempts++
+	status.LastAttemptTime = &metav1.Time{Time: start}
+	application.Status.SmokeTest = &status
+	if retry && status.Attempts <= smokeTest.Retries {
+		log.Info(fmt.Sprintf("log for <depid:%s> <pipeid:%s> Smoke test attempt %d against %s failed, %v", application.Spec.DeploymentId, application.Spec.PipelineId, status.Attempts, url, err))
+		if updateErr := r.updateStatus(ctx, application); updateErr != nil {
+			return false, 0, updateErr
+		}
+		return false, helpers.GetSmokeTestRetryInterval(smokeTest), nil
+	}
+	if retry {
+		err = fmt.Errorf("smoke test failed after %d attempts: %v", status.Attempts, err)
+	}
+
+	condition := metav1.Condition{
+		Type:               constants.ConditionTypeSmokeTestPassed,
+		Status:             metav1.ConditionTrue,
+		Reason:             "SmokeTestSucceeded",
+		Message:            fmt.Sprintf("smoke test against %s succeeded", url),
+		ObservedGeneration: application.Generation,
+	}
+	if err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "SmokeTestFailed"
+		condition.Message = helpers.RedactorFromContext(ctx).String(err.Error())
+		log.Error(err, fmt.Sprintf("log for <depid:%s> <pipeid:%s> ERROR: Smoke test failed, %v", application.Spec.DeploymentId, application.Spec.PipelineId, err))
+		helpers.RecordEvent(ctx, r.Recorder, application, corev1.EventTypeWarning, "SmokeTestFailed", "Smoke test failed: %v", err)
+	} else {
+		log.Info(fmt.Sprintf("log for <depid:%s> <pipeid:%s> Smoke test against %s succeeded", application.Spec.DeploymentId, application.Spec.PipelineId, url))
+		helpers.RecordEvent(ctx, r.Recorder, application, corev1.EventTypeNormal, "SmokeTestPassed", "Smoke test against %s succeeded", url)
+	}
+	meta.SetStatusCondition(&application.Status.Conditions, condition)
+	if updateErr := r.updateStatus(ctx, application); updateErr != nil {
+		log.Error(updateErr, fmt.Sprintf("log for <depid:%s> <pipeid:%s> ERROR: Failed to update Application status, %v", application.Spec.DeploymentId, application.Spec.PipelineId, updateErr))
+	}
+
+	details := helpers.WebhookStepDetails{DurationSeconds: webhook.SecondsSince(start)}
+	if err != nil {
+		details.Error = err.Error()
+		details.Reason = "SmokeTestFailed"
+	}
+	r.sendWebhook(ctx, application, constants.SmokeTestExecuted, err == nil, details)
+	return true, 0, err
+}
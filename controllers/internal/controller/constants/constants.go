@@ -10,6 +10,68 @@ const (
 	DeploymentFailed                  = "DEPLOYMENT_FAILED"
 	CreatedKubernetesResources        = "CREATED_KUBERNETES_RESOURCES"
 	DeploymentCompleted               = "DEPLOYMENT_COMPLETED"
+	DeploymentPlanned                 = "DEPLOYMENT_PLANNED"
+	KanikoJobExecuted                 = "KANIKO_JOB_EXECUTED"
+	ApplyModeApply                    = "apply"
+	ApplyModePlan                     = "plan"
+	ApplicationPhasePlanned           = "Planned"
+	ApplicationPhaseApplied           = "Applied"
+	DiffActionCreate                  = "create"
+	DiffActionUpdate                  = "update"
+	DiffActionUnchanged               = "unchanged"
+	DeploymentFrozen                  = "DEPLOYMENT_FROZEN"
+	ControllerNamespace               = "humalect"
+	FreezeOverrideAnnotation          = "humalect.com/freeze-override"
+	FreezeActionHold                  = "hold"
+	FreezeActionReject                = "reject"
+	ConditionTypeFrozen               = "Frozen"
+	DeploymentSetPhaseFrozen          = "Frozen"
+	DeploymentSetPhaseRejected        = "Rejected"
+	DeploymentSetPhaseJobCreated      = "JobCreated"
+	PreDeployHookExecuted             = "PRE_DEPLOY_HOOK_EXECUTED"
+	PostDeployHookExecuted            = "POST_DEPLOY_HOOK_EXECUTED"
+	DeploymentRolledBack              = "DEPLOYMENT_ROLLED_BACK"
+	HookFailurePolicyIgnore           = "Ignore"
+	HookFailurePolicyFail             = "Fail"
+	HookFailurePolicyRollback         = "Rollback"
+	ConditionTypeRolledBack           = "RolledBack"
+	ConditionTypeDeployFailed         = "DeployFailed"
+	ConditionTypeRolledOut            = "RolledOut"
+	DefaultHookTimeoutSeconds         = 1800
+	DeployProgressRequeueSeconds      = 10
+	SmokeTestExecuted                 = "SMOKE_TEST_EXECUTED"
+	SmokeTestTargetService            = "service"
+	SmokeTestTargetIngress            = "ingress"
+	ConditionTypeSmokeTestPassed      = "SmokeTestPassed"
+	DefaultSmokeTestRetryIntervalSecs = 5
+	DefaultSmokeTestTimeoutSeconds    = 10
+	WebhookDeliveryStatePending       = "Pending"
+	WebhookDeliveryStateDelivered     = "Delivered"
+	WebhookDeliveryStateFailed        = "Failed"
+	WebhookDeliveryMaxAgeSeconds      = 86400
+	WebhookDeliveryRetentionSeconds   = 86400
+	WebhookDeliveryMaxBackoffSeconds  = 600
+	CloudEventSourceController        = "/humalect-core/controller"
+	NotificationSinkWebhook           = "Webhook"
+	NotificationSinkSlack             = "Slack"
+	NotificationSinkTeams             = "Teams"
+	NotificationSinkNATS              = "NATS"
+	NotificationSinkKafka             = "Kafka"
+	EventPublishTimeoutSeconds        = 10
+	BuildDefaultsConfigMapName        = "humalect-build-defaults"
+	BuildDefaultsPodKey               = "pod"
+	BuildDefaultsImagesKey            = "images"
+	BuildDefaultsKanikoFlagsKey       = "kanikoFlags"
+	BuildDefaultsNetworkKey           = "network"
+	DefaultAgentImageRepository       = "public.ecr.aws/survo/core-agent"
+	DefaultApprovalTimeoutSeconds     = 86400
+	AgentJobTimeoutMarginSeconds      = 300
+	AgentSourceCodeTokenEnv           = "HUMALECT_SOURCE_CODE_TOKEN"
+	AgentEcrCredentialsEnv            = "HUMALECT_ECR_CREDENTIALS"
+	AgentAcrCredentialsEnv            = "HUMALECT_ACR_CREDENTIALS"
+	AgentDockerHubCredentialsEnv      = "HUMALECT_DOCKER_HUB_CREDENTIALS"
+	AgentAwsSecretCredentialsEnv      = "HUMALECT_AWS_SECRET_CREDENTIALS"
+	AgentAzureVaultCredentialsEnv     = "HUMALECT_AZURE_VAULT_CREDENTIALS"
 )
 
 type SecretConfig struct {
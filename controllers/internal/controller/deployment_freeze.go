@@ -0,0 +1,184 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	k8sv1 "github.com/Humalect/humalect-core/api/v1"
+	constants "github.com/Humalect/humalect-core/internal/controller/constants"
+	helpers "github.com/Humalect/humalect-core/internal/controller/helpers"
+)
+
+const (
+	freezeReasonHeld     = "DeploymentHeld"
+	freezeReasonRejected = "DeploymentRejected"
+	freezeReasonEnded    = "FreezeEnded"
+	freezeReasonOverride = "FreezeOverridden"
+)
+
+func getFreezeCondition(freeze *helpers.DeploymentFreeze, generation int64) metav1.Condition {
+	reason := freezeReasonHeld
+	if freeze.Action == constants.FreezeActionReject {
+		reason = freezeReasonRejected
+	}
+	return metav1.Condition{
+		Type:               constants.ConditionTypeFrozen,
+		Status:             metav1.ConditionTrue,
+		Reason:             reason,
+		Message:            freeze.Message(),
+		ObservedGeneration: generation,
+	}
+}
+
+func getFreezeEndedCondition(generation int64) metav1.Condition {
+	return metav1.Condition{
+		Type:               constants.ConditionTypeFrozen,
+		Status:             metav1.ConditionFalse,
+		Reason:             freezeReasonEnded,
+		Message:            "No deployment freeze is active",
+		ObservedGeneration: generation,
+	}
+}
+
+// getFreezeOverriddenCondition lifts a hold or a rejection once the override annotation is set.
+func getFreezeOverriddenCondition(generation int64) metav1.Condition {
+	return metav1.Condition{
+		Type:               constants.ConditionTypeFrozen,
+		Status:             metav1.ConditionFalse,
+		Reason:             freezeReasonOverride,
+		Message:            fmt.Sprintf("The deployment freeze is overridden by the %s annotation", constants.FreezeOverrideAnnotation),
+		ObservedGeneration: generation,
+	}
+}
+
+// reportInvalidFreezePolicies records a warning on each policy that was skipped because it can not be evaluated.
+func reportInvalidFreezePolicies(ctx context.Context, recorder record.EventRecorder, invalidPolicies []helpers.InvalidFreezePolicy) {
+	log := log.FromContext(ctx)
+	for i := range invalidPolicies {
+		policy := &invalidPolicies[i].Policy
+		log.Error(invalidPolicies[i].Err, fmt.Sprintf("Skipping invalid freeze policy %s/%s", policy.Namespace, policy.Name))
+		helpers.RecordEvent(ctx, recorder, policy, corev1.EventTypeWarning, "InvalidFreezePolicy", "Freeze policy is skipped: %v", invalidPolicies[i].Err)
+	}
+}
+
+func isRejectedByFreeze(conditions []metav1.Condition, generation int64) bool {
+	condition := meta.FindStatusCondition(conditions, constants.ConditionTypeFrozen)
+	return condition != nil && condition.Status == metav1.ConditionTrue && condition.Reason == freezeReasonRejected && condition.ObservedGeneration == generation
+}
+
+// holdForDeploymentFreeze reports whether the DeploymentSet must not start a new deploy because of an active
+// freeze. Held deploys are requeued until the freeze ends, rejected ones are only started once the override
+// annotation is set.
+func (r *DeploymentSetReconciler) holdForDeploymentFreeze(ctx context.Context, deploymentSet *k8sv1.DeploymentSet) (bool, ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	// The override is checked first so that it also releases a rejected deploy.
+	if helpers.IsFreezeOverridden(deploymentSet) {
+		if meta.IsStatusConditionTrue(deploymentSet.Status.Conditions, constants.ConditionTypeFrozen) {
+			meta.SetStatusCondition(&deploymentSet.Status.Conditions, getFreezeOverriddenCondition(deploymentSet.Generation))
+			if err := r.updateStatus(ctx, deploymentSet); err != nil {
+				return true, ctrl.Result{}, err
+			}
+		}
+		return false, ctrl.Result{}, nil
+	}
+	if deploymentSet.Status.Phase == constants.DeploymentSetPhaseRejected {
+		return true, ctrl.Result{}, nil
+	}
+	freeze, invalidPolicies, err := helpers.GetActiveDeploymentFreeze(ctx, r.Client, deploymentSet.Spec.Namespace, time.Now())
+	reportInvalidFreezePolicies(ctx, r.Recorder, invalidPolicies)
+	if err != nil {
+		log.Error(err, fmt.Sprintf("log for <depid:%s> <pipeid:%s> ERROR: Failed to check deployment freeze, %v", deploymentSet.Spec.DeploymentId, deploymentSet.Spec.PipelineId, err))
+		return true, ctrl.Result{}, err
+	}
+	if freeze == nil {
+		if meta.IsStatusConditionTrue(deploymentSet.Status.Conditions, constants.ConditionTypeFrozen) {
+			meta.SetStatusCondition(&deploymentSet.Status.Conditions, getFreezeEndedCondition(deploymentSet.Generation))
+			if err := r.updateStatus(ctx, deploymentSet); err != nil {
+				return true, ctrl.Result{}, err
+			}
+		}
+		return false, ctrl.Result{}, nil
+	}
+
+	log.Info(fmt.Sprintf("log for <depid:%s> <pipeid:%s> Deployment frozen, %s", deploymentSet.Spec.DeploymentId, deploymentSet.Spec.PipelineId, freeze.Message()))
+	notified := meta.IsStatusConditionTrue(deploymentSet.Status.Conditions, constants.ConditionTypeFrozen)
+	meta.SetStatusCondition(&deploymentSet.Status.Conditions, getFreezeCondition(freeze, deploymentSet.Generation))
+	deploymentSet.Status.Phase = constants.DeploymentSetPhaseFrozen
+	if freeze.Action == constants.FreezeActionReject {
+		deploymentSet.Status.Phase = constants.DeploymentSetPhaseRejected
+	}
+	if err := r.updateStatus(ctx, deploymentSet); err != nil {
+		return true, ctrl.Result{}, err
+	}
+
+	held := freeze.Action != constants.FreezeActionReject
+	if !notified || !held {
+		deploymentSet.Spec.WebhookData = helpers.UpdateWebhookDataField(deploymentSet.Spec.WebhookData, "freezeReason", freeze.Message())
+		deploymentSet.Spec.WebhookData = helpers.UpdateStatusData(deploymentSet.Spec.WebhookData, constants.DeploymentFrozen, held)
+		helpers.SendWebhook(deploymentSet.Spec.WebhookEndpoint, deploymentSet.Spec.WebhookData, held, constants.DeploymentFrozen)
+	}
+	if !held {
+		return true, ctrl.Result{}, nil
+	}
+	return true, ctrl.Result{RequeueAfter: time.Until(freeze.Until)}, nil
+}
+
+// holdForDeploymentFreeze reports whether the Application must not be rolled out because of an active freeze.
+func (r *ApplicationReconciler) holdForDeploymentFreeze(ctx context.Context, application *k8sv1.Application) (bool, ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	if helpers.IsFreezeOverridden(application) {
+		if meta.IsStatusConditionTrue(application.Status.Conditions, constants.ConditionTypeFrozen) {
+			meta.SetStatusCondition(&application.Status.Conditions, getFreezeOverriddenCondition(application.Generation))
+			if err := r.updateStatus(ctx, application); err != nil {
+				return true, ctrl.Result{}, err
+			}
+		}
+		return false, ctrl.Result{}, nil
+	}
+	if isRejectedByFreeze(application.Status.Conditions, application.Generation) {
+		return true, ctrl.Result{}, nil
+	}
+	freeze, invalidPolicies, err := helpers.GetActiveDeploymentFreeze(ctx, r.Client, application.GetNamespace(), time.Now())
+	reportInvalidFreezePolicies(ctx, r.Recorder, invalidPolicies)
+	if err != nil {
+		log.Error(err, fmt.Sprintf("log for <depid:%s> <pipeid:%s> ERROR: Failed to check deployment freeze, %v", application.Spec.DeploymentId, application.Spec.PipelineId, err))
+		return true, ctrl.Result{}, err
+	}
+	if freeze == nil {
+		if meta.IsStatusConditionTrue(application.Status.Conditions, constants.ConditionTypeFrozen) {
+			meta.SetStatusCondition(&application.Status.Conditions, getFreezeEndedCondition(application.Generation))
+			if err := r.updateStatus(ctx, application); err != nil {
+				return true, ctrl.Result{}, err
+			}
+		}
+		return false, ctrl.Result{}, nil
+	}
+
+	log.Info(fmt.Sprintf("log for <depid:%s> <pipeid:%s> Deployment frozen, %s", application.Spec.DeploymentId, application.Spec.PipelineId, freeze.Message()))
+	notified := meta.IsStatusConditionTrue(application.Status.Conditions, constants.ConditionTypeFrozen)
+	meta.SetStatusCondition(&application.Status.Conditions, getFreezeCondition(freeze, application.Generation))
+	if err := r.updateStatus(ctx, application); err != nil {
+		return true, ctrl.Result{}, err
+	}
+
+	held := freeze.Action != constants.FreezeActionReject
+	if !notified || !held {
+		application.Spec.WebhookData = helpers.UpdateWebhookDataField(application.Spec.WebhookData, "freezeReason", freeze.Message())
+		application.Spec.WebhookData = helpers.UpdateStatusData(application.Spec.WebhookData, constants.DeploymentFrozen, held)
+		helpers.SendWebhook(application.Spec.WebhookEndpoint, application.Spec.WebhookData, held, constants.DeploymentFrozen)
+	}
+	if !held {
+		return true, ctrl.Result{}, nil
+	}
+	return true, ctrl.Result{RequeueAfter: time.Until(freeze.Until)}, nil
+}
@@ -23,11 +23,13 @@ import (
 	"math"
 	"os"
 	"reflect"
+	"sort"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -35,7 +37,10 @@ import (
 	k8sv1 "github.com/Humalect/humalect-core/api/v1"
 	constants "github.com/Humalect/humalect-core/internal/controller/constants"
 	helpers "github.com/Humalect/humalect-core/internal/controller/helpers"
+	"github.com/Humalect/humalect-core/pkg/tracing"
 	"github.com/joho/godotenv"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 )
@@ -43,12 +48,14 @@ import (
 // DeploymentSetReconciler reconciles a DeploymentSet object
 type DeploymentSetReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
 }
 
 //+kubebuilder:rbac:groups=k8s.humalect.com,resources=deploymentsets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=k8s.humalect.com,resources=deploymentsets/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=k8s.humalect.com,resources=deploymentsets/finalizers,verbs=update
+//+kubebuilder:rbac:groups=k8s.humalect.com,resources=freezepolicies,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -92,8 +99,10 @@ func (r *DeploymentSetReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		log.Error(err, fmt.Sprintf("log for <depid:%s> <pipeid:%s> ERROR: Failed to get Application, %v", deploymentSet.Spec.DeploymentId, deploymentSet.Spec.PipelineId, err))
 		return ctrl.Result{}, err
 	}
+	ctx = helpers.ContextWithRedactor(ctx, helpers.GetDeploymentSetRedactor(deploymentSet))
 
 	if !deploymentSet.DeletionTimestamp.IsZero() {
+		r.Recorder.Event(deploymentSet, corev1.EventTypeNormal, "Deleting", "DeploymentSet is being deleted")
 		deploymentSet.ObjectMeta.Finalizers = removeString(deploymentSet.ObjectMeta.Finalizers, deploymentSetFinalizer)
 		if err := r.Update(ctx, deploymentSet); err != nil {
 			log.Error(err, fmt.Sprintf("log for <depid:%s> <pipeid:%s> ERROR: There is some error, %v", deploymentSet.Spec.DeploymentId, deploymentSet.Spec.PipelineId, err))
@@ -101,6 +110,13 @@ func (r *DeploymentSetReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		}
 		return ctrl.Result{}, nil
 	}
+	deploymentSet.Spec.WebhookData = helpers.UpdateWebhookDataId(deploymentSet.Spec.WebhookData, "deploymentId", deploymentSet.Spec.DeploymentId)
+	deploymentSet.Spec.WebhookData = helpers.UpdateWebhookDataField(deploymentSet.Spec.WebhookData, "startedAt", deploymentSet.CreationTimestamp.Time)
+	deploymentSet.Spec.WebhookData = helpers.UpdateWebhookDataField(deploymentSet.Spec.WebhookData, "commitId", deploymentSet.Spec.CommitId)
+	deploymentSet.Spec.WebhookData = helpers.UpdateWebhookDataField(deploymentSet.Spec.WebhookData, "pipelineId", deploymentSet.Spec.PipelineId)
+	deploymentSet.Spec.WebhookData = helpers.UpdateWebhookDataField(deploymentSet.Spec.WebhookData, "namespace", deploymentSet.Spec.Namespace)
+	deploymentSet.Spec.WebhookData = helpers.UpdateWebhookDataField(deploymentSet.Spec.WebhookData, "managedBy", deploymentSet.Spec.ManagedBy)
+	deploymentSet.Spec.WebhookData = helpers.UpdateWebhookDataFormat(deploymentSet.Spec.WebhookData, deploymentSet.Spec.WebhookFormat, deploymentSet.Spec.WebhookContentMode)
 
 	ingressYamlManifest, err := json.Marshal(deploymentSet.Spec.IngressYamlManifest)
 	if err != nil {
@@ -171,6 +187,50 @@ func (r *DeploymentSetReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		sendDeploymentJobCreatedWebhook(*deploymentSet, false)
 	}
 
+	hooks, err := json.Marshal(deploymentSet.Spec.Hooks)
+	if err != nil {
+		deploymentSet.Spec.WebhookData = helpers.UpdateStatusData(deploymentSet.Spec.WebhookData, constants.DeploymentJobCreated, false)
+		sendDeploymentJobCreatedWebhook(*deploymentSet, false)
+	}
+
+	smokeTest, err := json.Marshal(deploymentSet.Spec.SmokeTest)
+	if err != nil {
+		deploymentSet.Spec.WebhookData = helpers.UpdateStatusData(deploymentSet.Spec.WebhookData, constants.DeploymentJobCreated, false)
+		sendDeploymentJobCreatedWebhook(*deploymentSet, false)
+	}
+
+	buildDefaults, err := helpers.GetBuildDefaults(ctx, r.Client)
+	if err != nil {
+		log.Error(err, fmt.Sprintf("log for <depid:%s> <pipeid:%s> ERROR: Error reading build defaults, %v", deploymentSet.Spec.DeploymentId, deploymentSet.Spec.PipelineId, err))
+		return ctrl.Result{}, err
+	}
+	// The defaults are resolved once here, so the kaniko pod and the agent pod get the same settings.
+	buildSpec := helpers.MergeBuildSpec(deploymentSet.Spec.Build, buildDefaults.Build)
+	build, err := json.Marshal(buildSpec)
+	if err != nil {
+		log.Error(err, fmt.Sprintf("log for <depid:%s> <pipeid:%s> ERROR: Error encoding the build settings, %v", deploymentSet.Spec.DeploymentId, deploymentSet.Spec.PipelineId, err))
+		deploymentSet.Spec.WebhookData = helpers.UpdateStatusData(deploymentSet.Spec.WebhookData, constants.DeploymentJobCreated, false)
+		sendDeploymentJobCreatedWebhook(*deploymentSet, false)
+		return ctrl.Result{}, nil
+	}
+
+	network, err := json.Marshal(buildDefaults.Network)
+	if err != nil {
+		log.Error(err, fmt.Sprintf("log for <depid:%s> <pipeid:%s> ERROR: Error encoding the network settings, %v", deploymentSet.Spec.DeploymentId, deploymentSet.Spec.PipelineId, err))
+		deploymentSet.Spec.WebhookData = helpers.UpdateStatusData(deploymentSet.Spec.WebhookData, constants.DeploymentJobCreated, false)
+		sendDeploymentJobCreatedWebhook(*deploymentSet, false)
+		return ctrl.Result{}, nil
+	}
+
+	agentImage := constants.DefaultAgentImageRepository + ":" + agentImageTag
+	// A configured image is pulled the way Kubernetes does by default, so images pinned by digest or tag
+	// are not pulled for every deployment.
+	agentImagePullPolicy := corev1.PullAlways
+	if buildSpec.Images.Agent != "" {
+		agentImage = buildSpec.Images.Agent
+		agentImagePullPolicy = ""
+	}
+
 	jobObj := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: fmt.Sprintf("%s-ds-%s-%s",
@@ -185,20 +245,15 @@ func (r *DeploymentSetReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 					Containers: []corev1.Container{
 						{
 							Name:            fmt.Sprintf("%s-ds-%s", deploymentSet.Spec.ManagedBy, deploymentSet.Spec.CommitId),
-							Image:           "public.ecr.aws/survo/core-agent:" + agentImageTag,
-							ImagePullPolicy: corev1.PullPolicy("Always"),
+							Image:           agentImage,
+							ImagePullPolicy: agentImagePullPolicy,
+							Env:             getAgentEnv(),
 							Args: []string{
 								fmt.Sprintf("--artifactsRegistryProvider=%s", deploymentSet.Spec.ArtifactsRegistryProvider),
 								fmt.Sprintf("--secretsProvider=%s", deploymentSet.Spec.SecretsProvider),
-								fmt.Sprintf("--ecrCredentials=%s", ecrCredentials),
-								fmt.Sprintf("--acrCredentials=%s", acrCredentials),
-								fmt.Sprintf("--dockerHubCredentials=%s", dockerHubCredentials),
-								fmt.Sprintf("--awsSecretCredentials=%s", awsSecretCredentials),
-								fmt.Sprintf("--azureVaultCredentials=%s", azureVaultCredentials),
 								fmt.Sprintf("--cloudProvider=%s", deploymentSet.Spec.CloudProvider),
 								fmt.Sprintf("--sourceCodeRepositoryName=%s", deploymentSet.Spec.SourceCodeRepositoryName),
 								fmt.Sprintf("--sourceCodeProvider=%s", deploymentSet.Spec.SourceCodeProvider),
-								fmt.Sprintf("--sourceCodeToken=%s", deploymentSet.Spec.SourceCodeToken),
 								fmt.Sprintf("--sourceCodeOrgName=%s", deploymentSet.Spec.SourceCodeOrgName),
 								fmt.Sprintf("--commitId=%s", deploymentSet.Spec.CommitId),
 								fmt.Sprintf("--dockerManifest=%s", DockerManifest),
@@ -218,6 +273,17 @@ func (r *DeploymentSetReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 								fmt.Sprintf("--pipelineId=%s", deploymentSet.Spec.PipelineId),
 								fmt.Sprintf("--webhookEndpoint=%s", deploymentSet.Spec.WebhookEndpoint),
 								fmt.Sprintf("--webhookData=%s", helpers.UpdateStatusData(deploymentSet.Spec.WebhookData, constants.DeploymentJobCreated, true)),
+								fmt.Sprintf("--applyMode=%s", deploymentSet.Spec.ApplyMode),
+								fmt.Sprintf("--deploymentSetName=%s", deploymentSet.GetName()),
+								fmt.Sprintf("--deploymentSetNamespace=%s", deploymentSet.GetNamespace()),
+								fmt.Sprintf("--requireApproval=%t", deploymentSet.Spec.RequireApproval),
+								fmt.Sprintf("--approvalTimeoutSeconds=%d", deploymentSet.Spec.ApprovalTimeoutSeconds),
+								fmt.Sprintf("--hooks=%s", hooks),
+								fmt.Sprintf("--smokeTest=%s", smokeTest),
+								fmt.Sprintf("--webhookFormat=%s", deploymentSet.Spec.WebhookFormat),
+								fmt.Sprintf("--webhookContentMode=%s", deploymentSet.Spec.WebhookContentMode),
+								fmt.Sprintf("--build=%s", build),
+								fmt.Sprintf("--network=%s", network),
 							},
 						},
 					},
@@ -228,12 +294,29 @@ func (r *DeploymentSetReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 			BackoffLimit: &backoffLimit,
 		},
 	}
+	// The credentials are read from a Secret so they do not show up in the Job spec.
+	credentialsSecret := getAgentCredentialsSecret(jobObj.GetName(), map[string]string{
+		constants.AgentSourceCodeTokenEnv:       deploymentSet.Spec.SourceCodeToken,
+		constants.AgentEcrCredentialsEnv:        string(ecrCredentials),
+		constants.AgentAcrCredentialsEnv:        string(acrCredentials),
+		constants.AgentDockerHubCredentialsEnv:  string(dockerHubCredentials),
+		constants.AgentAwsSecretCredentialsEnv:  string(awsSecretCredentials),
+		constants.AgentAzureVaultCredentialsEnv: string(azureVaultCredentials),
+	})
+	jobObj.Spec.Template.Spec.Containers[0].Env = append(jobObj.Spec.Template.Spec.Containers[0].Env, getSecretEnvVars(credentialsSecret)...)
+	jobObj.Spec.Template.Spec.Containers[0].Resources = buildSpec.Pod.AgentResources
+	helpers.ApplyBuildPodScheduling(&jobObj.Spec.Template.Spec, buildSpec.Pod)
+	helpers.ApplyNetworkSettings(&jobObj.Spec.Template.Spec, &jobObj.Spec.Template.Spec.Containers[0], buildDefaults.Network)
+	jobObj.Spec.ActiveDeadlineSeconds = getAgentJobTimeout(*deploymentSet, buildSpec.Pod)
 	emptyObj := createEmptyObject(jobObj)
 
 	jobObj.SetNamespace("humalect")
 
 	if err := r.Get(ctx, client.ObjectKey{Name: jobObj.GetName(), Namespace: "humalect"}, emptyObj); err != nil {
 		if errors.IsNotFound(err) {
+			if frozen, res, err := r.holdForDeploymentFreeze(ctx, deploymentSet); frozen {
+				return res, err
+			}
 
 			// controllerRef := metav1.NewControllerRef(deploymentSet, k8sv1.GroupVersion.WithKind("DeploymentSet"))
 			// jobObj.SetOwnerReferences(append(jobObj.GetOwnerReferences(), *controllerRef))
@@ -256,6 +339,19 @@ func (r *DeploymentSetReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 			// }
 			// flag.Parse()
 
+			// The root span of the deployment is derived from the deploymentId and ended at the terminal event of
+			// the deployment, the agent and the Application continue its trace.
+			ctx = tracing.ContextWithDeploymentTrace(ctx, deploymentSet.Spec.DeploymentId)
+			deploymentSet.Status.TraceParent = tracing.GetTraceParent(ctx)
+			ctx, span := tracing.StartSpan(ctx, "create-agent-job", trace.WithAttributes(
+				attribute.String("humalect.deployment_id", deploymentSet.Spec.DeploymentId),
+				attribute.String("humalect.pipeline_id", deploymentSet.Spec.PipelineId),
+				attribute.String("humalect.namespace", deploymentSet.Spec.Namespace),
+				attribute.String("humalect.managed_by", deploymentSet.Spec.ManagedBy),
+			))
+			agentContainer := &jobObj.Spec.Template.Spec.Containers[0]
+			agentContainer.Args = append(agentContainer.Args, fmt.Sprintf("--traceParent=%s", deploymentSet.Status.TraceParent))
+
 			config := helpers.GetK8sConfig()
 
 			clientset, err := kubernetes.NewForConfig(config)
@@ -263,19 +359,45 @@ func (r *DeploymentSetReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 				log.Error(err, fmt.Sprintf("log for <depid:%s> <pipeid:%s> ERROR: Error creating clientset, %v", deploymentSet.Spec.DeploymentId, deploymentSet.Spec.PipelineId, err))
 				deploymentSet.Spec.WebhookData = helpers.UpdateStatusData(deploymentSet.Spec.WebhookData, constants.DeploymentJobCreated, false)
 
-				sendDeploymentJobCreatedWebhook(*deploymentSet, false)
+				helpers.SendWebhookWithDetails(deploymentSet.Spec.WebhookEndpoint, deploymentSet.Spec.WebhookData, false, constants.DeploymentJobCreated, helpers.RedactDetails(ctx, helpers.WebhookStepDetails{Error: err.Error(), Reason: "JobCreateFailed", Terminal: true}))
+				helpers.RecordEvent(ctx, r.Recorder, deploymentSet, corev1.EventTypeWarning, "JobCreateFailed", "Failed to create agent Job: %v", err)
+				tracing.EndSpan(span, err)
+				// The failure is reported once, a requeue would send the failed webhook again.
+				return ctrl.Result{}, nil
 			}
 			jobClient := clientset.BatchV1().Jobs("humalect")
 			jobObj.SetNamespace("humalect")
-			_, err = jobClient.Create(context.Background(), jobObj, metav1.CreateOptions{})
+			err = applyAgentCredentialsSecret(clientset, credentialsSecret)
+			var createdJob *batchv1.Job
+			if err == nil {
+				createdJob, err = jobClient.Create(context.Background(), jobObj, metav1.CreateOptions{})
+				if err != nil {
+					deleteAgentCredentialsSecret(clientset, credentialsSecret)
+				}
+			}
 			if err != nil {
+				log.Error(err, fmt.Sprintf("log for <depid:%s> <pipeid:%s> ERROR: Failed to create agent Job, %v", deploymentSet.Spec.DeploymentId, deploymentSet.Spec.PipelineId, err))
 				deploymentSet.Spec.WebhookData = helpers.UpdateStatusData(deploymentSet.Spec.WebhookData, constants.DeploymentJobCreated, false)
 
-				sendDeploymentJobCreatedWebhook(*deploymentSet, false)
+				helpers.SendWebhookWithDetails(deploymentSet.Spec.WebhookEndpoint, deploymentSet.Spec.WebhookData, false, constants.DeploymentJobCreated, helpers.RedactDetails(ctx, helpers.WebhookStepDetails{Error: err.Error(), Reason: "JobCreateFailed", Terminal: true}))
+				helpers.RecordEvent(ctx, r.Recorder, deploymentSet, corev1.EventTypeWarning, "JobCreateFailed", "Failed to create agent Job %s: %v", jobObj.GetName(), err)
+				tracing.EndSpan(span, err)
+				return ctrl.Result{}, nil
+			}
+			// The Secret is removed together with the Job.
+			if err := setAgentCredentialsSecretOwner(clientset, credentialsSecret, createdJob); err != nil {
+				log.Error(err, fmt.Sprintf("log for <depid:%s> <pipeid:%s> ERROR: Failed to set the owner of the agent credentials Secret, %v", deploymentSet.Spec.DeploymentId, deploymentSet.Spec.PipelineId, err))
 			}
 			deploymentSet.Spec.WebhookData = helpers.UpdateStatusData(deploymentSet.Spec.WebhookData, constants.DeploymentJobCreated, true)
 
 			sendDeploymentJobCreatedWebhook(*deploymentSet, true)
+			helpers.RecordEvent(ctx, r.Recorder, deploymentSet, corev1.EventTypeNormal, "JobCreated", "Created agent Job humalect/%s", jobObj.GetName())
+			span.SetAttributes(attribute.String("humalect.agent_job", jobObj.GetName()))
+			tracing.EndSpan(span, nil)
+			deploymentSet.Status.Phase = constants.DeploymentSetPhaseJobCreated
+			if err := r.updateStatus(ctx, deploymentSet); err != nil {
+				log.Error(err, fmt.Sprintf("log for <depid:%s> <pipeid:%s> ERROR: Failed to update DeploymentSet status, %v", deploymentSet.Spec.DeploymentId, deploymentSet.Spec.PipelineId, err))
+			}
 		} else {
 			deploymentSet.Spec.WebhookData = helpers.UpdateStatusData(deploymentSet.Spec.WebhookData, constants.DeploymentJobCreated, false)
 
@@ -294,6 +416,107 @@ func (r *DeploymentSetReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	return ctrl.Result{}, nil
 }
 
+// getAgentJobTimeout bounds the agent Job by the timeout of the build. The agent also waits for an approval
+// and creates the resources around the build, so those are added to the deadline.
+func getAgentJobTimeout(deploymentSet k8sv1.DeploymentSet, pod k8sv1.BuildPodSpec) *int64 {
+	if pod.TimeoutSeconds == nil {
+		return nil
+	}
+	timeoutSeconds := *pod.TimeoutSeconds + constants.AgentJobTimeoutMarginSeconds
+	if deploymentSet.Spec.RequireApproval {
+		approvalTimeoutSeconds := deploymentSet.Spec.ApprovalTimeoutSeconds
+		if approvalTimeoutSeconds <= 0 {
+			approvalTimeoutSeconds = constants.DefaultApprovalTimeoutSeconds
+		}
+		timeoutSeconds += approvalTimeoutSeconds
+	}
+	return &timeoutSeconds
+}
+
+// getAgentEnv passes the webhook client, trace exporter and log settings of the controller on to the agent.
+func getAgentEnv() []corev1.EnvVar {
+	env := []corev1.EnvVar{}
+	for _, key := range []string{"WEBHOOK_TIMEOUT_SECONDS", "WEBHOOK_MAX_RETRIES", "WEBHOOK_SIGNING_SECRET_NAME", "WEBHOOK_SIGNING_SECRET_KEY", "OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "LOG_FORMAT", "LOG_LEVEL"} {
+		if value, exists := os.LookupEnv(key); exists {
+			env = append(env, corev1.EnvVar{Name: key, Value: value})
+		}
+	}
+	return env
+}
+
+func getAgentCredentialsSecret(jobName string, credentials map[string]string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-credentials", jobName), Namespace: "humalect"},
+		StringData: credentials,
+	}
+}
+
+// getSecretEnvVars sets an environment variable of the same name for every key of the Secret.
+func getSecretEnvVars(secret *corev1.Secret) []corev1.EnvVar {
+	keys := make([]string, 0, len(secret.StringData))
+	for key := range secret.StringData {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	env := []corev1.EnvVar{}
+	for _, key := range keys {
+		env = append(env, corev1.EnvVar{
+			Name: key,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: secret.GetName()},
+					Key:                  key,
+				},
+			},
+		})
+	}
+	return env
+}
+
+// applyAgentCredentialsSecret creates the credentials Secret of the agent Job, or replaces the one left
+// behind by a Job that could not be created.
+func applyAgentCredentialsSecret(clientset *kubernetes.Clientset, secret *corev1.Secret) error {
+	secretClient := clientset.CoreV1().Secrets(secret.GetNamespace())
+	_, err := secretClient.Create(context.Background(), secret, metav1.CreateOptions{})
+	if !errors.IsAlreadyExists(err) {
+		return err
+	}
+	existing, err := secretClient.Get(context.Background(), secret.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	existing.Data = nil
+	existing.StringData = secret.StringData
+	_, err = secretClient.Update(context.Background(), existing, metav1.UpdateOptions{})
+	return err
+}
+
+func deleteAgentCredentialsSecret(clientset *kubernetes.Clientset, secret *corev1.Secret) {
+	if err := clientset.CoreV1().Secrets(secret.GetNamespace()).Delete(context.Background(), secret.GetName(), metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		fmt.Printf("Error deleting agent credentials Secret %s: %v\n", secret.GetName(), err)
+	}
+}
+
+// setAgentCredentialsSecretOwner makes the Job the owner of the Secret so it is garbage collected with it.
+func setAgentCredentialsSecretOwner(clientset *kubernetes.Clientset, secret *corev1.Secret, job *batchv1.Job) error {
+	secretClient := clientset.CoreV1().Secrets(secret.GetNamespace())
+	existing, err := secretClient.Get(context.Background(), secret.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	existing.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(job, batchv1.SchemeGroupVersion.WithKind("Job"))}
+	_, err = secretClient.Update(context.Background(), existing, metav1.UpdateOptions{})
+	return err
+}
+
+// updateStatus keeps the in-memory webhook data, like ApplicationReconciler.updateStatus.
+func (r *DeploymentSetReconciler) updateStatus(ctx context.Context, deploymentSet *k8sv1.DeploymentSet) error {
+	webhookData := deploymentSet.Spec.WebhookData
+	err := r.Status().Update(ctx, deploymentSet)
+	deploymentSet.Spec.WebhookData = webhookData
+	return err
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *DeploymentSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
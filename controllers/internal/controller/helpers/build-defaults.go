@@ -0,0 +1,155 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+
+	k8sv1 "github.com/Humalect/humalect-core/api/v1"
+	constants "github.com/Humalect/humalect-core/internal/controller/constants"
+	"github.com/Humalect/humalect-core/pkg/netconfig"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// BuildDefaults are the cluster settings of the builds. The build is merged into the build of every
+// DeploymentSet, the network settings only exist at the cluster level.
+type BuildDefaults struct {
+	Build   k8sv1.BuildSpec
+	Network netconfig.Settings
+}
+
+// GetBuildDefaults reads the cluster defaults of the builds from the humalect-build-defaults ConfigMap.
+// There are no defaults when the ConfigMap does not exist.
+func GetBuildDefaults(ctx context.Context, c client.Client) (BuildDefaults, error) {
+	configMap := &corev1.ConfigMap{}
+	err := c.Get(ctx, client.ObjectKey{Namespace: constants.ControllerNamespace, Name: constants.BuildDefaultsConfigMapName}, configMap)
+	if errors.IsNotFound(err) {
+		return BuildDefaults{}, nil
+	}
+	if err != nil {
+		return BuildDefaults{}, err
+	}
+	return ParseBuildDefaults(configMap)
+}
+
+// ParseBuildDefaults reads the pod, images, kanikoFlags and network keys of the ConfigMap, each holds its
+// settings in YAML or JSON.
+func ParseBuildDefaults(configMap *corev1.ConfigMap) (BuildDefaults, error) {
+	defaults := BuildDefaults{}
+	for key, field := range map[string]interface{}{
+		constants.BuildDefaultsPodKey:         &defaults.Build.Pod,
+		constants.BuildDefaultsImagesKey:      &defaults.Build.Images,
+		constants.BuildDefaultsKanikoFlagsKey: &defaults.Build.KanikoFlags,
+		constants.BuildDefaultsNetworkKey:     &defaults.Network,
+	} {
+		value, ok := configMap.Data[key]
+		if !ok {
+			continue
+		}
+		if err := yaml.UnmarshalStrict([]byte(value), field); err != nil {
+			return BuildDefaults{}, fmt.Errorf("invalid %s in configmap %s/%s: %v", key, configMap.Namespace, configMap.Name, err)
+		}
+	}
+	return defaults, nil
+}
+
+// MergeBuildSpec fills the pod and images of the build with the defaults. The kaniko flags of the build
+// come after the flags of the cluster, so they win for flags that can only be set once.
+func MergeBuildSpec(build k8sv1.BuildSpec, defaults k8sv1.BuildSpec) k8sv1.BuildSpec {
+	merged := *build.DeepCopy()
+	merged.Pod = MergeBuildPodSpec(build.Pod, defaults.Pod)
+	for _, image := range []struct {
+		value        *string
+		defaultValue string
+	}{
+		{&merged.Images.Git, defaults.Images.Git},
+		{&merged.Images.Kaniko, defaults.Images.Kaniko},
+		{&merged.Images.Warmer, defaults.Images.Warmer},
+		{&merged.Images.Agent, defaults.Images.Agent},
+	} {
+		if *image.value == "" {
+			*image.value = image.defaultValue
+		}
+	}
+	if len(defaults.KanikoFlags) > 0 {
+		merged.KanikoFlags = append(append([]string{}, defaults.KanikoFlags...), build.KanikoFlags...)
+	}
+	return merged
+}
+
+// MergeBuildPodSpec fills the fields of the build pod that are not set with the defaults. Resources are
+// merged per resource name, so a default ephemeral-storage limit stays when only cpu is set.
+func MergeBuildPodSpec(pod k8sv1.BuildPodSpec, defaults k8sv1.BuildPodSpec) k8sv1.BuildPodSpec {
+	merged := *pod.DeepCopy()
+	merged.Resources = mergeResourceRequirements(merged.Resources, defaults.Resources)
+	merged.AgentResources = mergeResourceRequirements(merged.AgentResources, defaults.AgentResources)
+	if merged.TimeoutSeconds == nil && defaults.TimeoutSeconds != nil {
+		timeoutSeconds := *defaults.TimeoutSeconds
+		merged.TimeoutSeconds = &timeoutSeconds
+	}
+	if merged.BackoffLimit == nil && defaults.BackoffLimit != nil {
+		backoffLimit := *defaults.BackoffLimit
+		merged.BackoffLimit = &backoffLimit
+	}
+	if merged.NodeSelector == nil && defaults.NodeSelector != nil {
+		merged.NodeSelector = map[string]string{}
+		for key, value := range defaults.NodeSelector {
+			merged.NodeSelector[key] = value
+		}
+	}
+	if merged.Tolerations == nil && defaults.Tolerations != nil {
+		merged.Tolerations = append([]corev1.Toleration{}, defaults.Tolerations...)
+	}
+	if merged.Affinity == nil && defaults.Affinity != nil {
+		merged.Affinity = defaults.Affinity.DeepCopy()
+	}
+	if merged.PriorityClassName == "" {
+		merged.PriorityClassName = defaults.PriorityClassName
+	}
+	if merged.WorkspaceSizeLimit == nil && defaults.WorkspaceSizeLimit != nil {
+		workspaceSizeLimit := defaults.WorkspaceSizeLimit.DeepCopy()
+		merged.WorkspaceSizeLimit = &workspaceSizeLimit
+	}
+	return merged
+}
+
+// mergeResourceRequirements fills the requests and limits that are not set with the defaults, without
+// producing a request above its limit. A default request is lowered to the limit, and a default limit below
+// the request that is set is left out.
+func mergeResourceRequirements(resources corev1.ResourceRequirements, defaults corev1.ResourceRequirements) corev1.ResourceRequirements {
+	for name, limit := range defaults.Limits {
+		if _, ok := resources.Limits[name]; ok {
+			continue
+		}
+		if request, ok := resources.Requests[name]; ok && request.Cmp(limit) > 0 {
+			continue
+		}
+		if resources.Limits == nil {
+			resources.Limits = corev1.ResourceList{}
+		}
+		resources.Limits[name] = limit.DeepCopy()
+	}
+	for name, request := range defaults.Requests {
+		if _, ok := resources.Requests[name]; ok {
+			continue
+		}
+		if limit, ok := resources.Limits[name]; ok && request.Cmp(limit) > 0 {
+			request = limit
+		}
+		if resources.Requests == nil {
+			resources.Requests = corev1.ResourceList{}
+		}
+		resources.Requests[name] = request.DeepCopy()
+	}
+	return resources
+}
+
+// ApplyBuildPodScheduling sets where the pods of a deployment are scheduled.
+func ApplyBuildPodScheduling(podSpec *corev1.PodSpec, pod k8sv1.BuildPodSpec) {
+	podSpec.NodeSelector = pod.NodeSelector
+	podSpec.Tolerations = pod.Tolerations
+	podSpec.Affinity = pod.Affinity
+	podSpec.PriorityClassName = pod.PriorityClassName
+}
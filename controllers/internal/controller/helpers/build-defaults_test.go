@@ -0,0 +1,167 @@
+package helpers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	k8sv1 "github.com/Humalect/humalect-core/api/v1"
+	constants "github.com/Humalect/humalect-core/internal/controller/constants"
+)
+
+func TestMergeBuildPodSpec(t *testing.T) {
+	configMap := &corev1.ConfigMap{Data: map[string]string{"pod": `
+resources:
+  requests:
+    cpu: 500m
+  limits:
+    cpu: "2"
+    ephemeral-storage: 10Gi
+timeoutSeconds: 1800
+backoffLimit: 1
+nodeSelector:
+  pool: builds
+priorityClassName: low
+`}}
+	defaults, err := ParseBuildDefaults(configMap)
+	if err != nil {
+		t.Fatalf("ParseBuildDefaults() error = %v", err)
+	}
+
+	timeoutSeconds := int64(600)
+	pod := k8sv1.BuildPodSpec{
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+		},
+		TimeoutSeconds: &timeoutSeconds,
+		NodeSelector:   map[string]string{"pool": "large"},
+	}
+	merged := MergeBuildPodSpec(pod, defaults.Build.Pod)
+
+	if got := merged.Resources.Limits[corev1.ResourceCPU]; got.String() != "4" {
+		t.Errorf("cpu limit = %s, want 4", got.String())
+	}
+	if got := merged.Resources.Limits[corev1.ResourceEphemeralStorage]; got.String() != "10Gi" {
+		t.Errorf("ephemeral-storage limit = %s, want 10Gi", got.String())
+	}
+	if got := merged.Resources.Requests[corev1.ResourceCPU]; got.String() != "500m" {
+		t.Errorf("cpu request = %s, want 500m", got.String())
+	}
+	if *merged.TimeoutSeconds != 600 {
+		t.Errorf("timeoutSeconds = %d, want 600", *merged.TimeoutSeconds)
+	}
+	if merged.BackoffLimit == nil || *merged.BackoffLimit != 1 {
+		t.Errorf("backoffLimit = %v, want 1", merged.BackoffLimit)
+	}
+	if merged.NodeSelector["pool"] != "large" {
+		t.Errorf("nodeSelector = %v, want the node selector of the build", merged.NodeSelector)
+	}
+	if merged.PriorityClassName != "low" {
+		t.Errorf("priorityClassName = %q, want low", merged.PriorityClassName)
+	}
+	if _, ok := pod.Resources.Limits[corev1.ResourceEphemeralStorage]; ok {
+		t.Errorf("MergeBuildPodSpec() changed the resources of the build")
+	}
+}
+
+func TestMergeBuildPodSpecResources(t *testing.T) {
+	list := func(cpu string) corev1.ResourceList {
+		return corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}
+	}
+	defaults := corev1.ResourceRequirements{Requests: list("500m"), Limits: list("2")}
+
+	tests := []struct {
+		name        string
+		resources   corev1.ResourceRequirements
+		wantRequest string
+		wantLimit   string
+	}{
+		{name: "defaults", wantRequest: "500m", wantLimit: "2"},
+		{name: "default request above the limit", resources: corev1.ResourceRequirements{Limits: list("250m")}, wantRequest: "250m", wantLimit: "250m"},
+		{name: "default limit below the request", resources: corev1.ResourceRequirements{Requests: list("3")}, wantRequest: "3"},
+		{name: "both set", resources: corev1.ResourceRequirements{Requests: list("100m"), Limits: list("200m")}, wantRequest: "100m", wantLimit: "200m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := MergeBuildPodSpec(k8sv1.BuildPodSpec{Resources: tt.resources}, k8sv1.BuildPodSpec{Resources: defaults})
+			request := merged.Resources.Requests[corev1.ResourceCPU]
+			if request.String() != tt.wantRequest {
+				t.Errorf("cpu request = %s, want %s", request.String(), tt.wantRequest)
+			}
+			limit, ok := merged.Resources.Limits[corev1.ResourceCPU]
+			if tt.wantLimit == "" {
+				if ok {
+					t.Errorf("cpu limit = %s, want none", limit.String())
+				}
+				return
+			}
+			if limit.String() != tt.wantLimit {
+				t.Errorf("cpu limit = %s, want %s", limit.String(), tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestMergeBuildSpecImagesAndKanikoFlags(t *testing.T) {
+	configMap := &corev1.ConfigMap{Data: map[string]string{
+		"images":      "kaniko: mirror.local/kaniko-project/executor@sha256:abc\ngit: mirror.local/alpine/git:2.40.1\n",
+		"kanikoFlags": `["--registry-mirror=mirror.local"]`,
+	}}
+	defaults, err := ParseBuildDefaults(configMap)
+	if err != nil {
+		t.Fatalf("ParseBuildDefaults() error = %v", err)
+	}
+	build := k8sv1.BuildSpec{
+		Images:      k8sv1.BuildImagesSpec{Git: "registry.local/git:1"},
+		KanikoFlags: []string{"--snapshot-mode=redo"},
+	}
+	merged := MergeBuildSpec(build, defaults.Build)
+
+	if merged.Images.Git != "registry.local/git:1" {
+		t.Errorf("git image = %q, want the image of the build", merged.Images.Git)
+	}
+	if merged.Images.Kaniko != "mirror.local/kaniko-project/executor@sha256:abc" {
+		t.Errorf("kaniko image = %q, want the default image", merged.Images.Kaniko)
+	}
+	if merged.Images.Agent != "" {
+		t.Errorf("agent image = %q, want no image", merged.Images.Agent)
+	}
+	if len(merged.KanikoFlags) != 2 || merged.KanikoFlags[0] != "--registry-mirror=mirror.local" || merged.KanikoFlags[1] != "--snapshot-mode=redo" {
+		t.Errorf("kaniko flags = %v, want the flags of the cluster followed by the flags of the build", merged.KanikoFlags)
+	}
+	if len(build.KanikoFlags) != 1 {
+		t.Errorf("MergeBuildSpec() changed the kaniko flags of the build")
+	}
+}
+
+func TestParseBuildDefaultsRejectsUnknownFields(t *testing.T) {
+	configMap := &corev1.ConfigMap{Data: map[string]string{"pod": "timeout: 1800\n"}}
+	if _, err := ParseBuildDefaults(configMap); err == nil {
+		t.Errorf("ParseBuildDefaults() error = nil, want an error for an unknown field")
+	}
+}
+
+func TestGetBuildDefaults(t *testing.T) {
+	ctx := context.Background()
+	defaults, err := GetBuildDefaults(ctx, fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build())
+	if err != nil || len(defaults.Build.KanikoFlags) != 0 {
+		t.Fatalf("GetBuildDefaults() = %+v, %v, want no defaults without the ConfigMap", defaults, err)
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: constants.ControllerNamespace, Name: constants.BuildDefaultsConfigMapName},
+		Data:       map[string]string{"kanikoFlags": `["--snapshot-mode=redo"]`},
+	}
+	defaults, err = GetBuildDefaults(ctx, fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(configMap).Build())
+	if err != nil {
+		t.Fatalf("GetBuildDefaults() error = %v", err)
+	}
+	if len(defaults.Build.KanikoFlags) != 1 || defaults.Build.KanikoFlags[0] != "--snapshot-mode=redo" {
+		t.Errorf("kaniko flags = %v", defaults.Build.KanikoFlags)
+	}
+}
@@ -17,6 +17,14 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 )
 
+// GetSecretsProvider returns the provider application secrets are fetched from, it defaults to the cloud provider.
+func GetSecretsProvider(application *k8sv1.Application) string {
+	if application.Spec.SecretsProvider != "" {
+		return application.Spec.SecretsProvider
+	}
+	return application.Spec.CloudProvider
+}
+
 func GetCloudSecretMap(application *k8sv1.Application, secretConfig k8sv1.SecretConfig) (map[string]string, error) {
 	var secretsMap map[string]string
 	var secretString string
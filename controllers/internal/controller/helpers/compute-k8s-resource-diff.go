@@ -0,0 +1,130 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	k8sv1 "github.com/Humalect/humalect-core/api/v1"
+	constants "github.com/Humalect/humalect-core/internal/controller/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ComputeK8sResourceDiff compares the desired objects with the ones living in the cluster.
+// Only the fields set on the desired object are compared, so values defaulted by the
+// api server do not show up as changes.
+func ComputeK8sResourceDiff(ctx context.Context, namespace string, r *ApplicationReconciler, objs ...Object) ([]k8sv1.ResourceDiff, error) {
+	diffs := []k8sv1.ResourceDiff{}
+	for _, obj := range objs {
+		kind := reflect.TypeOf(obj).Elem().Name()
+		liveObj := createEmptyObject(obj)
+		if err := r.Get(ctx, client.ObjectKey{Name: obj.GetName(), Namespace: namespace}, liveObj); err != nil {
+			if errors.IsNotFound(err) {
+				diffs = append(diffs, k8sv1.ResourceDiff{Kind: kind, Name: obj.GetName(), Action: constants.DiffActionCreate})
+				continue
+			}
+			return diffs, err
+		}
+
+		var diff k8sv1.ResourceDiff
+		if secret, ok := obj.(*corev1.Secret); ok {
+			diff = getSecretDiff(secret, liveObj.(*corev1.Secret))
+		} else {
+			fields, err := getChangedFields(obj, liveObj)
+			if err != nil {
+				return diffs, err
+			}
+			diff = k8sv1.ResourceDiff{Fields: fields}
+		}
+		diff.Kind = kind
+		diff.Name = obj.GetName()
+		diff.Action = constants.DiffActionUpdate
+		if len(diff.Fields) == 0 && len(diff.AddedKeys) == 0 && len(diff.RemovedKeys) == 0 && len(diff.ChangedKeys) == 0 {
+			diff.Action = constants.DiffActionUnchanged
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs, nil
+}
+
+func getSecretDiff(desired *corev1.Secret, live *corev1.Secret) k8sv1.ResourceDiff {
+	diff := k8sv1.ResourceDiff{}
+	for key, value := range desired.StringData {
+		liveValue, ok := live.Data[key]
+		if !ok {
+			diff.AddedKeys = append(diff.AddedKeys, key)
+		} else if string(liveValue) != value {
+			diff.ChangedKeys = append(diff.ChangedKeys, key)
+		}
+	}
+	for key := range live.Data {
+		if _, ok := desired.StringData[key]; !ok {
+			diff.RemovedKeys = append(diff.RemovedKeys, key)
+		}
+	}
+	sort.Strings(diff.AddedKeys)
+	sort.Strings(diff.ChangedKeys)
+	sort.Strings(diff.RemovedKeys)
+	return diff
+}
+
+func getChangedFields(desired Object, live Object) ([]string, error) {
+	desiredMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(desired)
+	if err != nil {
+		return nil, err
+	}
+	liveMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(live)
+	if err != nil {
+		return nil, err
+	}
+	fields := []string{}
+	for _, key := range []string{"spec"} {
+		fields = append(fields, compareFields(key, desiredMap[key], liveMap[key])...)
+	}
+	for _, key := range []string{"labels", "annotations"} {
+		desiredMeta, _ := desiredMap["metadata"].(map[string]interface{})
+		liveMeta, _ := liveMap["metadata"].(map[string]interface{})
+		fields = append(fields, compareFields("metadata."+key, desiredMeta[key], liveMeta[key])...)
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
+
+func compareFields(path string, desired interface{}, live interface{}) []string {
+	switch desiredValue := desired.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		if len(desiredValue) == 0 {
+			return nil
+		}
+		liveValue, ok := live.(map[string]interface{})
+		if !ok {
+			return []string{path}
+		}
+		fields := []string{}
+		for key, value := range desiredValue {
+			fields = append(fields, compareFields(path+"."+key, value, liveValue[key])...)
+		}
+		return fields
+	case []interface{}:
+		liveValue, ok := live.([]interface{})
+		if !ok || len(liveValue) != len(desiredValue) {
+			return []string{path}
+		}
+		fields := []string{}
+		for i := range desiredValue {
+			fields = append(fields, compareFields(fmt.Sprintf("%s[%d]", path, i), desiredValue[i], liveValue[i])...)
+		}
+		return fields
+	default:
+		if !reflect.DeepEqual(desired, live) {
+			return []string{path}
+		}
+		return nil
+	}
+}
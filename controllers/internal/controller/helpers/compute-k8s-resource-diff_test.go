@@ -0,0 +1,92 @@
+package helpers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	k8sv1 "github.com/Humalect/humalect-core/api/v1"
+	constants "github.com/Humalect/humalect-core/internal/controller/constants"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestComputeK8sResourceDiff(t *testing.T) {
+	replicas := int32(2)
+	liveDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default", Labels: map[string]string{"app": "api"}},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "api", Image: "api:v1", ImagePullPolicy: corev1.PullIfNotPresent}},
+			}},
+		},
+	}
+	liveSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-env", Namespace: "default"},
+		Data:       map[string][]byte{"KEPT": []byte("same"), "CHANGED": []byte("old"), "REMOVED": []byte("gone")},
+	}
+	r := &ApplicationReconciler{
+		Client: fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(liveDeployment, liveSecret).Build(),
+	}
+
+	changedDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Labels: map[string]string{"app": "api"}},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "api", Image: "api:v2"}},
+			}},
+		},
+	}
+	unchangedDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "api"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-env"},
+		StringData: map[string]string{"KEPT": "same", "CHANGED": "new", "ADDED": "value"},
+	}
+	service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "api"}}
+
+	tests := []struct {
+		name string
+		obj  Object
+		want k8sv1.ResourceDiff
+	}{
+		{
+			name: "missing object is created",
+			obj:  service,
+			want: k8sv1.ResourceDiff{Kind: "Service", Name: "api", Action: constants.DiffActionCreate},
+		},
+		{
+			name: "changed field, defaulted fields are ignored",
+			obj:  changedDeployment,
+			want: k8sv1.ResourceDiff{Kind: "Deployment", Name: "api", Action: constants.DiffActionUpdate, Fields: []string{"spec.template.spec.containers[0].image"}},
+		},
+		{
+			name: "unset fields are unchanged",
+			obj:  unchangedDeployment,
+			want: k8sv1.ResourceDiff{Kind: "Deployment", Name: "api", Action: constants.DiffActionUnchanged, Fields: []string{}},
+		},
+		{
+			name: "secret keys without values",
+			obj:  secret,
+			want: k8sv1.ResourceDiff{Kind: "Secret", Name: "api-env", Action: constants.DiffActionUpdate, AddedKeys: []string{"ADDED"}, ChangedKeys: []string{"CHANGED"}, RemovedKeys: []string{"REMOVED"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs, err := ComputeK8sResourceDiff(context.Background(), "default", r, tt.obj)
+			if err != nil {
+				t.Fatalf("ComputeK8sResourceDiff() error = %v", err)
+			}
+			if len(diffs) != 1 || !reflect.DeepEqual(diffs[0], tt.want) {
+				t.Errorf("ComputeK8sResourceDiff() = %+v, want %+v", diffs, tt.want)
+			}
+		})
+	}
+}
@@ -9,16 +9,19 @@ import (
 	constants "github.com/Humalect/humalect-core/internal/controller/constants"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 type ApplicationReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
 }
 type Object interface {
 	metav1.Object
@@ -31,6 +34,10 @@ func createEmptyObject(obj Object) Object {
 	return emptyObj
 }
 
+func getObjectKind(obj Object) string {
+	return reflect.TypeOf(obj).Elem().Name()
+}
+
 func CreateK8sResource(ctx context.Context, application *k8sv1.Application, namespace string, r *ApplicationReconciler, objs ...Object) (ctrl.Result, error) {
 	log := log.FromContext(ctx)
 	for _, obj := range objs {
@@ -49,10 +56,12 @@ func CreateK8sResource(ctx context.Context, application *k8sv1.Application, name
 
 				if err := r.Create(ctx, obj); err != nil {
 					log.Error(err, "Failed to create", reflect.TypeOf(obj).String(), obj.GetName())
+					RecordEvent(ctx, r.Recorder, application, corev1.EventTypeWarning, "CreateFailed", "Failed to create %s %s: %v", getObjectKind(obj), obj.GetName(), err)
 					SendWebhook(application.Spec.WebhookEndpoint, application.Spec.WebhookData, false, constants.CreatedKubernetesResources)
 					return ctrl.Result{}, err
 				}
 				log.Info("Created Resource", reflect.TypeOf(obj).String(), obj.GetName())
+				RecordEvent(ctx, r.Recorder, application, corev1.EventTypeNormal, "Created", "Created %s %s", getObjectKind(obj), obj.GetName())
 			} else {
 				log.Error(err, "Failed to get", reflect.TypeOf(emptyObj).String(), obj.GetName())
 				SendWebhook(application.Spec.WebhookEndpoint, application.Spec.WebhookData, false, constants.CreatedKubernetesResources)
@@ -63,8 +72,12 @@ func CreateK8sResource(ctx context.Context, application *k8sv1.Application, name
 			controllerRef := metav1.NewControllerRef(application, k8sv1.GroupVersion.WithKind("Application"))
 			obj.SetOwnerReferences(append(obj.GetOwnerReferences(), *controllerRef))
 			if err := r.Update(ctx, obj); err != nil {
+				RecordEvent(ctx, r.Recorder, application, corev1.EventTypeWarning, "UpdateFailed", "Failed to update %s %s: %v", getObjectKind(obj), obj.GetName(), err)
 				return ctrl.Result{}, err
 			}
+			if obj.GetResourceVersion() != emptyObj.GetResourceVersion() {
+				RecordEvent(ctx, r.Recorder, application, corev1.EventTypeNormal, "Updated", "Updated %s %s", getObjectKind(obj), obj.GetName())
+			}
 		}
 	}
 
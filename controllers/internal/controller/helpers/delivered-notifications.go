@@ -0,0 +1,36 @@
+package helpers
+
+import (
+	"time"
+
+	k8sv1 "github.com/Humalect/humalect-core/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IsNotificationDelivered reports whether the step was already sent for the deployment with the same outcome.
+func IsNotificationDelivered(notifications []k8sv1.DeliveredNotification, deploymentId string, step string, success bool) bool {
+	for _, notification := range notifications {
+		if notification.DeploymentId == deploymentId && notification.Step == step {
+			return notification.Success == success
+		}
+	}
+	return false
+}
+
+// SetNotificationDelivered records the outcome sent for a step. Records of earlier deployments are dropped,
+// only the current deployment can still be notified about.
+func SetNotificationDelivered(notifications []k8sv1.DeliveredNotification, deploymentId string, step string, success bool, now time.Time) []k8sv1.DeliveredNotification {
+	updated := []k8sv1.DeliveredNotification{}
+	for _, notification := range notifications {
+		if notification.DeploymentId != deploymentId || notification.Step == step {
+			continue
+		}
+		updated = append(updated, notification)
+	}
+	return append(updated, k8sv1.DeliveredNotification{
+		DeploymentId: deploymentId,
+		Step:         step,
+		Success:      success,
+		SentTime:     metav1.NewTime(now),
+	})
+}
@@ -0,0 +1,30 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetNotificationDelivered(t *testing.T) {
+	notifications := SetNotificationDelivered(nil, "dep-1", "DEPLOYMENT_COMPLETED", true, time.Now())
+	if !IsNotificationDelivered(notifications, "dep-1", "DEPLOYMENT_COMPLETED", true) {
+		t.Fatalf("expected the completed step to be delivered, got %+v", notifications)
+	}
+	if IsNotificationDelivered(notifications, "dep-1", "DEPLOYMENT_COMPLETED", false) {
+		t.Fatal("a changed outcome must be sent again")
+	}
+	if IsNotificationDelivered(notifications, "dep-2", "DEPLOYMENT_COMPLETED", true) {
+		t.Fatal("a new deployment must be sent again")
+	}
+
+	notifications = SetNotificationDelivered(notifications, "dep-1", "DEPLOYMENT_COMPLETED", false, time.Now())
+	notifications = SetNotificationDelivered(notifications, "dep-1", "SMOKE_TEST_EXECUTED", true, time.Now())
+	if len(notifications) != 2 || !IsNotificationDelivered(notifications, "dep-1", "DEPLOYMENT_COMPLETED", false) {
+		t.Fatalf("unexpected notifications %+v", notifications)
+	}
+
+	notifications = SetNotificationDelivered(notifications, "dep-2", "DEPLOYMENT_COMPLETED", true, time.Now())
+	if len(notifications) != 1 || notifications[0].DeploymentId != "dep-2" {
+		t.Fatalf("records of earlier deployments should be dropped, got %+v", notifications)
+	}
+}
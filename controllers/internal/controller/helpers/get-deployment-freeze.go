@@ -0,0 +1,135 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	k8sv1 "github.com/Humalect/humalect-core/api/v1"
+	constants "github.com/Humalect/humalect-core/internal/controller/constants"
+	"github.com/robfig/cron/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type DeploymentFreeze struct {
+	PolicyName string
+	Action     string
+	Reason     string
+	Until      time.Time
+}
+
+func (f *DeploymentFreeze) Message() string {
+	return fmt.Sprintf("%s (policy %s, until %s)", f.Reason, f.PolicyName, f.Until.UTC().Format(time.RFC3339))
+}
+
+// InvalidFreezePolicy is a policy that can not be evaluated. It is skipped so that it does not block every
+// deploy.
+type InvalidFreezePolicy struct {
+	Policy k8sv1.FreezePolicy
+	Err    error
+}
+
+func IsFreezeOverridden(obj metav1.Object) bool {
+	return obj.GetAnnotations()[constants.FreezeOverrideAnnotation] == "true"
+}
+
+// GetActiveDeploymentFreeze returns the freeze that currently applies to the namespace, or nil when
+// deployments are allowed, together with the policies that were skipped because they are invalid.
+// Policies from the controller namespace apply to every namespace. When several policies are active a
+// reject wins over a hold, and among policies with the same action the one that ends last is returned.
+func GetActiveDeploymentFreeze(ctx context.Context, c client.Client, namespace string, now time.Time) (*DeploymentFreeze, []InvalidFreezePolicy, error) {
+	policies := []k8sv1.FreezePolicy{}
+	for _, policyNamespace := range []string{constants.ControllerNamespace, namespace} {
+		policyList := &k8sv1.FreezePolicyList{}
+		if err := c.List(ctx, policyList, client.InNamespace(policyNamespace)); err != nil {
+			return nil, nil, err
+		}
+		policies = append(policies, policyList.Items...)
+		if namespace == constants.ControllerNamespace {
+			break
+		}
+	}
+
+	var activeFreeze *DeploymentFreeze
+	invalidPolicies := []InvalidFreezePolicy{}
+	for _, policy := range policies {
+		if policy.Namespace == constants.ControllerNamespace && len(policy.Spec.Namespaces) > 0 && !containsNamespace(policy.Spec.Namespaces, namespace) {
+			continue
+		}
+		freeze, err := getPolicyFreeze(policy, now)
+		if err != nil {
+			invalidPolicies = append(invalidPolicies, InvalidFreezePolicy{Policy: policy, Err: err})
+			continue
+		}
+		if freeze != nil && (activeFreeze == nil || isStricterFreeze(freeze, activeFreeze)) {
+			activeFreeze = freeze
+		}
+	}
+	return activeFreeze, invalidPolicies, nil
+}
+
+// isStricterFreeze reports whether freeze takes precedence over current. Policy names break ties so the
+// result does not depend on the list order.
+func isStricterFreeze(freeze *DeploymentFreeze, current *DeploymentFreeze) bool {
+	freezeRejects := freeze.Action == constants.FreezeActionReject
+	currentRejects := current.Action == constants.FreezeActionReject
+	if freezeRejects != currentRejects {
+		return freezeRejects
+	}
+	if !freeze.Until.Equal(current.Until) {
+		return freeze.Until.After(current.Until)
+	}
+	return freeze.PolicyName < current.PolicyName
+}
+
+func getPolicyFreeze(policy k8sv1.FreezePolicy, now time.Time) (*DeploymentFreeze, error) {
+	action := policy.Spec.Action
+	if action == "" {
+		action = constants.FreezeActionHold
+	}
+	reason := policy.Spec.Reason
+	if reason == "" {
+		reason = "Deployments are frozen"
+	}
+
+	for _, period := range policy.Spec.Periods {
+		if !now.Before(period.Start.Time) && now.Before(period.End.Time) {
+			periodReason := reason
+			if period.Reason != "" {
+				periodReason = period.Reason
+			}
+			return &DeploymentFreeze{PolicyName: policy.Name, Action: action, Reason: periodReason, Until: period.End.Time}, nil
+		}
+	}
+
+	for _, window := range policy.Spec.Windows {
+		duration, err := time.ParseDuration(window.Duration)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration %q in freeze policy %s: %v", window.Duration, policy.Name, err)
+		}
+		spec := window.Schedule
+		if window.TimeZone != "" {
+			spec = fmt.Sprintf("CRON_TZ=%s %s", window.TimeZone, window.Schedule)
+		}
+		schedule, err := cron.ParseStandard(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q in freeze policy %s: %v", window.Schedule, policy.Name, err)
+		}
+		// The window is active when it started within the last duration.
+		start := schedule.Next(now.Add(-duration))
+		if !start.After(now) {
+			return &DeploymentFreeze{PolicyName: policy.Name, Action: action, Reason: reason, Until: start.Add(duration)}, nil
+		}
+	}
+	return nil, nil
+}
+
+func containsNamespace(namespaces []string, namespace string) bool {
+	for _, item := range namespaces {
+		if item == namespace {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+package helpers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	k8sv1 "github.com/Humalect/humalect-core/api/v1"
+	constants "github.com/Humalect/humalect-core/internal/controller/constants"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetPolicyFreeze(t *testing.T) {
+	// 2026-10-23 is a Friday.
+	at := func(value string) time.Time {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+	window := func(schedule string, duration string, timeZone string) k8sv1.FreezePolicySpec {
+		return k8sv1.FreezePolicySpec{Windows: []k8sv1.FreezeWindow{{Schedule: schedule, Duration: duration, TimeZone: timeZone}}}
+	}
+	period := k8sv1.FreezePolicySpec{
+		Action: constants.FreezeActionReject,
+		Periods: []k8sv1.FreezePeriod{{
+			Start:  metav1.NewTime(at("2026-12-24T00:00:00Z")),
+			End:    metav1.NewTime(at("2026-12-27T00:00:00Z")),
+			Reason: "Holidays",
+		}},
+	}
+
+	tests := []struct {
+		name       string
+		spec       k8sv1.FreezePolicySpec
+		now        string
+		wantActive bool
+		wantUntil  string
+		wantAction string
+		wantReason string
+		wantErr    bool
+	}{
+		{name: "inside window", spec: window("0 18 * * 5", "64h", ""), now: "2026-10-23T19:00:00Z", wantActive: true, wantUntil: "2026-10-26T10:00:00Z"},
+		{name: "weekend window crosses midnight", spec: window("0 18 * * 5", "64h", ""), now: "2026-10-25T23:30:00Z", wantActive: true, wantUntil: "2026-10-26T10:00:00Z"},
+		{name: "before window", spec: window("0 18 * * 5", "64h", ""), now: "2026-10-23T17:59:00Z"},
+		{name: "window end is exclusive", spec: window("0 18 * * 5", "64h", ""), now: "2026-10-26T10:00:00Z"},
+		{name: "nightly window after midnight", spec: window("0 22 * * *", "4h", ""), now: "2026-10-20T01:30:00Z", wantActive: true, wantUntil: "2026-10-20T02:00:00Z"},
+		{name: "nightly window before midnight", spec: window("0 22 * * *", "4h", ""), now: "2026-10-19T23:00:00Z", wantActive: true, wantUntil: "2026-10-20T02:00:00Z"},
+		{name: "nightly window during the day", spec: window("0 22 * * *", "4h", ""), now: "2026-10-20T12:00:00Z"},
+		{name: "window in time zone", spec: window("0 9 * * *", "2h", "Asia/Kolkata"), now: "2026-10-20T04:00:00Z", wantActive: true, wantUntil: "2026-10-20T05:30:00Z"},
+		{name: "schedule is not read as UTC", spec: window("0 9 * * *", "2h", "Asia/Kolkata"), now: "2026-10-20T10:00:00Z"},
+		{name: "midnight in time zone", spec: window("0 0 * * *", "2h", "America/New_York"), now: "2026-10-20T05:00:00Z", wantActive: true, wantUntil: "2026-10-20T06:00:00Z"},
+		{name: "period", spec: period, now: "2026-12-25T08:00:00Z", wantActive: true, wantUntil: "2026-12-27T00:00:00Z", wantAction: constants.FreezeActionReject, wantReason: "Holidays"},
+		{name: "period end is exclusive", spec: period, now: "2026-12-27T00:00:00Z"},
+		{name: "invalid duration", spec: window("0 18 * * 5", "2 days", ""), now: "2026-10-23T19:00:00Z", wantErr: true},
+		{name: "invalid schedule", spec: window("every friday", "1h", ""), now: "2026-10-23T19:00:00Z", wantErr: true},
+		{name: "invalid time zone", spec: window("0 18 * * 5", "1h", "Mars/Olympus"), now: "2026-10-23T19:00:00Z", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := k8sv1.FreezePolicy{ObjectMeta: metav1.ObjectMeta{Name: "freeze"}, Spec: tt.spec}
+			freeze, err := getPolicyFreeze(policy, at(tt.now))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getPolicyFreeze() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (freeze != nil) != tt.wantActive {
+				t.Fatalf("getPolicyFreeze() = %+v, want active %v", freeze, tt.wantActive)
+			}
+			if freeze == nil {
+				return
+			}
+			if !freeze.Until.Equal(at(tt.wantUntil)) {
+				t.Errorf("Until = %s, want %s", freeze.Until.UTC().Format(time.RFC3339), tt.wantUntil)
+			}
+			wantAction := tt.wantAction
+			if wantAction == "" {
+				wantAction = constants.FreezeActionHold
+			}
+			if freeze.Action != wantAction {
+				t.Errorf("Action = %q, want %q", freeze.Action, wantAction)
+			}
+			if tt.wantReason != "" && freeze.Reason != tt.wantReason {
+				t.Errorf("Reason = %q, want %q", freeze.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestGetActiveDeploymentFreezeSkipsInvalidPolicies(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := k8sv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	invalid := &k8sv1.FreezePolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "broken", Namespace: constants.ControllerNamespace},
+		Spec:       k8sv1.FreezePolicySpec{Windows: []k8sv1.FreezeWindow{{Schedule: "0 18 * * 5", Duration: "forever"}}},
+	}
+	valid := &k8sv1.FreezePolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "team-a"},
+		Spec:       k8sv1.FreezePolicySpec{Windows: []k8sv1.FreezeWindow{{Schedule: "0 22 * * *", Duration: "4h"}}},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(invalid, valid).Build()
+
+	freeze, invalidPolicies, err := GetActiveDeploymentFreeze(context.Background(), c, "team-a", time.Date(2026, 10, 19, 23, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GetActiveDeploymentFreeze() error = %v", err)
+	}
+	if freeze == nil || freeze.PolicyName != "nightly" {
+		t.Errorf("GetActiveDeploymentFreeze() = %+v, want the nightly freeze", freeze)
+	}
+	if len(invalidPolicies) != 1 || invalidPolicies[0].Policy.Name != "broken" || invalidPolicies[0].Err == nil {
+		t.Errorf("invalid policies = %+v, want the broken policy", invalidPolicies)
+	}
+
+	freeze, _, err = GetActiveDeploymentFreeze(context.Background(), c, "team-a", time.Date(2026, 10, 20, 12, 0, 0, 0, time.UTC))
+	if err != nil || freeze != nil {
+		t.Errorf("GetActiveDeploymentFreeze() = %+v, %v, want no freeze outside the window", freeze, err)
+	}
+}
+
+func TestGetActiveDeploymentFreezeOverlappingPolicies(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := k8sv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	// The hold policy sorts first and ends later, the overlapping reject must still win.
+	hold := &k8sv1.FreezePolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "a-weekend", Namespace: "team-a"},
+		Spec:       k8sv1.FreezePolicySpec{Windows: []k8sv1.FreezeWindow{{Schedule: "0 18 * * 5", Duration: "64h"}}},
+	}
+	reject := &k8sv1.FreezePolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "b-release", Namespace: constants.ControllerNamespace},
+		Spec:       k8sv1.FreezePolicySpec{Action: constants.FreezeActionReject, Windows: []k8sv1.FreezeWindow{{Schedule: "0 18 * * 5", Duration: "4h"}}},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(hold, reject).Build()
+
+	freeze, _, err := GetActiveDeploymentFreeze(context.Background(), c, "team-a", time.Date(2026, 10, 23, 19, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GetActiveDeploymentFreeze() error = %v", err)
+	}
+	if freeze == nil || freeze.PolicyName != "b-release" || freeze.Action != constants.FreezeActionReject {
+		t.Errorf("GetActiveDeploymentFreeze() = %+v, want the b-release rejection", freeze)
+	}
+
+	// Once the rejection ended only the hold applies.
+	freeze, _, err = GetActiveDeploymentFreeze(context.Background(), c, "team-a", time.Date(2026, 10, 24, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GetActiveDeploymentFreeze() error = %v", err)
+	}
+	if freeze == nil || freeze.PolicyName != "a-weekend" || freeze.Action != constants.FreezeActionHold {
+		t.Errorf("GetActiveDeploymentFreeze() = %+v, want the a-weekend hold", freeze)
+	}
+}
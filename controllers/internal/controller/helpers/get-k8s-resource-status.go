@@ -0,0 +1,64 @@
+package helpers
+
+import (
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// IsDeploymentRolledOut reports whether every replica of the Deployment runs the latest template. The
+// rollout fails once the Deployment exceeded its progress deadline, which is 600s unless the manifest sets
+// progressDeadlineSeconds.
+func IsDeploymentRolledOut(deployment *appsv1.Deployment) (bool, error) {
+	if deployment.Status.ObservedGeneration < deployment.Generation {
+		return false, nil
+	}
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Reason == "ProgressDeadlineExceeded" {
+			return false, fmt.Errorf("deployment %s exceeded its progress deadline", deployment.GetName())
+		}
+	}
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	return deployment.Status.UpdatedReplicas == replicas &&
+		deployment.Status.Replicas == replicas &&
+		deployment.Status.AvailableReplicas == replicas, nil
+}
+
+// IsJobFinished reports whether the Job succeeded or failed and returns an error when it failed.
+func IsJobFinished(job *batchv1.Job) (bool, error) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		if condition.Type == batchv1.JobComplete {
+			return true, nil
+		}
+		if condition.Type == batchv1.JobFailed {
+			return true, fmt.Errorf("job %s failed: %s", job.GetName(), condition.Message)
+		}
+	}
+	return false, nil
+}
+
+// GetJobDurationSeconds returns how long the Job ran, or has been running so far.
+func GetJobDurationSeconds(job *batchv1.Job) float64 {
+	if job.Status.StartTime == nil {
+		return 0
+	}
+	end := time.Now()
+	if job.Status.CompletionTime != nil {
+		end = job.Status.CompletionTime.Time
+	}
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			end = condition.LastTransitionTime.Time
+		}
+	}
+	return end.Sub(job.Status.StartTime.Time).Round(time.Millisecond).Seconds()
+}
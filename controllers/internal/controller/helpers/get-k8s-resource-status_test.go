@@ -0,0 +1,83 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsDeploymentRolledOut(t *testing.T) {
+	replicas := int32(2)
+	deployment := func(generation int64, status appsv1.DeploymentStatus) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Generation: generation},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status:     status,
+		}
+	}
+	tests := []struct {
+		name       string
+		deployment *appsv1.Deployment
+		want       bool
+		wantErr    bool
+	}{
+		{name: "rolled out", deployment: deployment(2, appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2}), want: true},
+		{name: "generation not observed yet", deployment: deployment(3, appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2})},
+		{name: "old replicas still running", deployment: deployment(2, appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 2, AvailableReplicas: 3})},
+		{name: "updated replicas not available", deployment: deployment(2, appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 1})},
+		{name: "progress deadline exceeded", deployment: deployment(2, appsv1.DeploymentStatus{
+			ObservedGeneration: 2,
+			Conditions:         []appsv1.DeploymentCondition{{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: "ProgressDeadlineExceeded"}},
+		}), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := IsDeploymentRolledOut(tt.deployment)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("IsDeploymentRolledOut() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("IsDeploymentRolledOut() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsJobFinished(t *testing.T) {
+	start := metav1.NewTime(time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC))
+	end := metav1.NewTime(start.Add(90 * time.Second))
+	tests := []struct {
+		name         string
+		status       batchv1.JobStatus
+		wantFinished bool
+		wantErr      bool
+		wantDuration float64
+	}{
+		{name: "running", status: batchv1.JobStatus{Active: 1}},
+		{name: "complete", status: batchv1.JobStatus{
+			StartTime:      &start,
+			CompletionTime: &end,
+			Conditions:     []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+		}, wantFinished: true, wantDuration: 90},
+		{name: "failed", status: batchv1.JobStatus{
+			StartTime:  &start,
+			Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "DeadlineExceeded", LastTransitionTime: end}},
+		}, wantFinished: true, wantErr: true, wantDuration: 90},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "migrate"}, Status: tt.status}
+			finished, err := IsJobFinished(job)
+			if finished != tt.wantFinished || (err != nil) != tt.wantErr {
+				t.Errorf("IsJobFinished() = %v, %v, want %v, error %v", finished, err, tt.wantFinished, tt.wantErr)
+			}
+			if tt.wantDuration != 0 && GetJobDurationSeconds(job) != tt.wantDuration {
+				t.Errorf("GetJobDurationSeconds() = %v, want %v", GetJobDurationSeconds(job), tt.wantDuration)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package helpers
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+func NewKafkaPublisher(servers []string) (EventPublisher, error) {
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(servers...),
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			MaxAttempts:  1,
+		},
+	}, nil
+}
+
+// Publish waits until every in-sync replica has the message. The idempotency key is the message key so all
+// events of a deployment step land on the same partition.
+func (p *KafkaPublisher) Publish(ctx context.Context, topic string, payload []byte, headers map[string]string, key string) error {
+	message := kafka.Message{
+		Topic: topic,
+		Key:   []byte(key),
+		Value: payload,
+	}
+	for name, value := range headers {
+		message.Headers = append(message.Headers, kafka.Header{Key: name, Value: []byte(value)})
+	}
+	return p.writer.WriteMessages(ctx, message)
+}
+
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,95 @@
+package helpers
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+	metadataAPI "github.com/segmentio/kafka-go/protocol/metadata"
+	produceAPI "github.com/segmentio/kafka-go/protocol/produce"
+)
+
+// fakeKafkaBroker answers metadata requests for a topic with a single partition and keeps the produced records.
+type fakeKafkaBroker struct {
+	mutex     sync.Mutex
+	errorCode int16
+	records   []*protocol.Record
+	keys      [][]byte
+	values    [][]byte
+}
+
+func (b *fakeKafkaBroker) RoundTrip(ctx context.Context, addr net.Addr, request kafka.Request) (kafka.Response, error) {
+	switch request := request.(type) {
+	case *metadataAPI.Request:
+		topics := []metadataAPI.ResponseTopic{}
+		for _, name := range request.TopicNames {
+			topics = append(topics, metadataAPI.ResponseTopic{Name: name, Partitions: []metadataAPI.ResponsePartition{{PartitionIndex: 0}}})
+		}
+		return &metadataAPI.Response{Brokers: []metadataAPI.ResponseBroker{{NodeID: 0, Host: "127.0.0.1", Port: 9092}}, Topics: topics}, nil
+	case *produceAPI.Request:
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		response := &produceAPI.Response{}
+		for _, topic := range request.Topics {
+			responseTopic := produceAPI.ResponseTopic{Topic: topic.Topic}
+			for _, partition := range topic.Partitions {
+				for {
+					record, err := partition.RecordSet.Records.ReadRecord()
+					if err != nil {
+						break
+					}
+					key, _ := protocol.ReadAll(record.Key)
+					value, _ := protocol.ReadAll(record.Value)
+					b.records = append(b.records, record)
+					b.keys = append(b.keys, key)
+					b.values = append(b.values, value)
+				}
+				responseTopic.Partitions = append(responseTopic.Partitions, produceAPI.ResponsePartition{Partition: partition.Partition, ErrorCode: b.errorCode})
+			}
+			response.Topics = append(response.Topics, responseTopic)
+		}
+		return response, nil
+	}
+	return nil, protocol.ErrNoRecord
+}
+
+func newTestKafkaPublisher(broker *fakeKafkaBroker) *KafkaPublisher {
+	publisher, _ := NewKafkaPublisher([]string{"127.0.0.1:9092"})
+	kafkaPublisher := publisher.(*KafkaPublisher)
+	kafkaPublisher.writer.Transport = broker
+	return kafkaPublisher
+}
+
+func TestKafkaPublisherPublish(t *testing.T) {
+	broker := &fakeKafkaBroker{}
+	publisher := newTestKafkaPublisher(broker)
+	defer publisher.Close()
+
+	err := publisher.Publish(context.Background(), "deployments", []byte(`{"step":"DEPLOYMENT_COMPLETED"}`), map[string]string{"X-Team": "payments"}, "key-1")
+	if err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if len(broker.records) != 1 {
+		t.Fatalf("broker got %d records, want 1", len(broker.records))
+	}
+	if string(broker.keys[0]) != "key-1" || string(broker.values[0]) != `{"step":"DEPLOYMENT_COMPLETED"}` {
+		t.Errorf("record key %q value %q", broker.keys[0], broker.values[0])
+	}
+	headers := broker.records[0].Headers
+	if len(headers) != 1 || headers[0].Key != "X-Team" || string(headers[0].Value) != "payments" {
+		t.Errorf("record headers = %v", headers)
+	}
+}
+
+func TestKafkaPublisherPublishNotAcknowledged(t *testing.T) {
+	broker := &fakeKafkaBroker{errorCode: int16(kafka.NotEnoughReplicas)}
+	publisher := newTestKafkaPublisher(broker)
+	defer publisher.Close()
+
+	if err := publisher.Publish(context.Background(), "deployments", []byte(`{}`), nil, "key-1"); err == nil {
+		t.Fatal("expected an error so the delivery is retried")
+	}
+}
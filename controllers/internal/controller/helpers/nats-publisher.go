@@ -0,0 +1,57 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	constants "github.com/Humalect/humalect-core/internal/controller/constants"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+type NATSPublisher struct {
+	conn *nats.Conn
+	js   jetstream.JetStream
+}
+
+func NewNATSPublisher(servers []string) (EventPublisher, error) {
+	conn, err := nats.Connect(strings.Join(servers, ","), nats.Name("humalect-core"), nats.Timeout(constants.EventPublishTimeoutSeconds*time.Second))
+	if err != nil {
+		return nil, err
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &NATSPublisher{conn: conn, js: js}, nil
+}
+
+// Publish waits for the PubAck of the JetStream stream that stores the topic, a publish without a stream or
+// without an acknowledgement fails. The idempotency key is sent as Nats-Msg-Id so the stream drops duplicates.
+func (p *NATSPublisher) Publish(ctx context.Context, topic string, payload []byte, headers map[string]string, key string) error {
+	msg := nats.NewMsg(topic)
+	msg.Data = payload
+	for name, value := range headers {
+		msg.Header.Set(name, value)
+	}
+	opts := []jetstream.PublishOpt{}
+	if key != "" {
+		opts = append(opts, jetstream.WithMsgID(key))
+	}
+	ack, err := p.js.PublishMsg(ctx, msg, opts...)
+	if err != nil {
+		return err
+	}
+	if ack == nil {
+		return fmt.Errorf("no acknowledgement for message published to %s", topic)
+	}
+	return nil
+}
+
+func (p *NATSPublisher) Close() error {
+	p.conn.Close()
+	return nil
+}
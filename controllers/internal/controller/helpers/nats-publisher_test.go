@@ -0,0 +1,121 @@
+package helpers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	k8sv1 "github.com/Humalect/humalect-core/api/v1"
+	constants "github.com/Humalect/humalect-core/internal/controller/constants"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+var testSink = k8sv1.WebhookDeliverySink{PolicyNamespace: "team-a", PolicyName: "events", SinkName: "bus", Type: constants.NotificationSinkNATS}
+
+func startNATSServer(t *testing.T) *server.Server {
+	t.Helper()
+	natsServer, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true, JetStream: true, StoreDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go natsServer.Start()
+	if !natsServer.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server did not start")
+	}
+	return natsServer
+}
+
+// createStream creates a stream that stores the deployments.events subject and returns a consumer of it.
+func createStream(t *testing.T, natsServer *server.Server) (*nats.Conn, jetstream.Consumer) {
+	t.Helper()
+	conn, err := nats.Connect(natsServer.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	stream, err := js.CreateStream(ctx, jetstream.StreamConfig{Name: "DEPLOYMENTS", Subjects: []string{"deployments.events"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	consumer, err := stream.CreateOrUpdateConsumer(ctx, jetstream.ConsumerConfig{Durable: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn, consumer
+}
+
+func TestPublishEventNATS(t *testing.T) {
+	natsServer := startNATSServer(t)
+	defer natsServer.Shutdown()
+	conn, consumer := createStream(t, natsServer)
+	defer conn.Close()
+
+	publishers := NewEventPublishers()
+	defer publishers.Close()
+	endpoint := NotificationSinkEndpoint{
+		Type:    constants.NotificationSinkNATS,
+		URL:     natsServer.ClientURL(),
+		Topic:   "deployments.events",
+		Headers: map[string]string{"X-Team": "payments"},
+	}
+	// The second publish is a retry of the same event and dropped by the stream.
+	for i := 0; i < 2; i++ {
+		if err := publishers.Publish(context.Background(), testSink, endpoint, []byte(`{"step":"DEPLOYMENT_COMPLETED"}`), "key-1"); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+	if err := publishers.Publish(context.Background(), testSink, endpoint, []byte(`{"step":"DEPLOYMENT_FAILED"}`), "key-2"); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	batch, err := consumer.FetchNoWait(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := []jetstream.Msg{}
+	for msg := range batch.Messages() {
+		messages = append(messages, msg)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("stream has %d messages, want 2", len(messages))
+	}
+	msg := messages[0]
+	if string(msg.Data()) != `{"step":"DEPLOYMENT_COMPLETED"}` || msg.Headers().Get(nats.MsgIdHdr) != "key-1" || msg.Headers().Get("X-Team") != "payments" {
+		t.Fatalf("unexpected message %q with headers %v", msg.Data(), msg.Headers())
+	}
+	// The test connection and the single connection of the sink.
+	if clients := natsServer.NumClients(); clients != 2 {
+		t.Errorf("server has %d clients, want the publisher to be reused", clients)
+	}
+}
+
+func TestPublishEventNATSWithoutStream(t *testing.T) {
+	natsServer := startNATSServer(t)
+	defer natsServer.Shutdown()
+
+	publishers := NewEventPublishers()
+	defer publishers.Close()
+	endpoint := NotificationSinkEndpoint{Type: constants.NotificationSinkNATS, URL: natsServer.ClientURL(), Topic: "deployments.events"}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := publishers.Publish(ctx, testSink, endpoint, []byte(`{}`), "key-1"); err == nil {
+		t.Fatal("expected an error when no stream acknowledges the message")
+	}
+}
+
+func TestPublishEventNATSUnavailable(t *testing.T) {
+	natsServer := startNATSServer(t)
+	url := natsServer.ClientURL()
+	natsServer.Shutdown()
+
+	endpoint := NotificationSinkEndpoint{Type: constants.NotificationSinkNATS, URL: url, Topic: "deployments.events"}
+	if err := NewEventPublishers().Publish(context.Background(), testSink, endpoint, []byte(`{}`), "key-1"); err == nil {
+		t.Fatal("expected an error so the delivery is retried")
+	}
+}
@@ -0,0 +1,21 @@
+package helpers
+
+import (
+	"github.com/Humalect/humalect-core/pkg/netconfig"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ApplyNetworkSettings sets the proxy of the agent container and mounts the CA bundle, the agent adds it
+// to the CAs of its HTTP clients.
+func ApplyNetworkSettings(podSpec *corev1.PodSpec, container *corev1.Container, network netconfig.Settings) {
+	container.Env = append(container.Env, netconfig.GetProxyEnv(network)...)
+	if network.CABundleConfigMap == "" {
+		return
+	}
+	podSpec.Volumes = append(podSpec.Volumes, netconfig.GetCABundleVolume(network))
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      netconfig.CABundleVolumeName,
+		MountPath: netconfig.CABundleMountPath,
+		ReadOnly:  true,
+	})
+}
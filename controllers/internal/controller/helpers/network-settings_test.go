@@ -0,0 +1,61 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/Humalect/humalect-core/pkg/netconfig"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestApplyNetworkSettings(t *testing.T) {
+	configMap := &corev1.ConfigMap{Data: map[string]string{"network": `
+caBundleConfigMap: internal-ca
+caBundleKey: bundle.pem
+httpsProxy: http://proxy.internal:3128
+noProxy: 10.0.0.1,.svc
+insecureRegistries:
+- harbor.internal
+`}}
+	defaults, err := ParseBuildDefaults(configMap)
+	if err != nil {
+		t.Fatalf("ParseBuildDefaults() error = %v", err)
+	}
+
+	podSpec := corev1.PodSpec{Containers: []corev1.Container{{Name: "agent"}}}
+	ApplyNetworkSettings(&podSpec, &podSpec.Containers[0], defaults.Network)
+
+	env := map[string]string{}
+	for _, envVar := range podSpec.Containers[0].Env {
+		env[envVar.Name] = envVar.Value
+	}
+	for _, name := range []string{"HTTPS_PROXY", "https_proxy"} {
+		if env[name] != "http://proxy.internal:3128" {
+			t.Errorf("%s = %q, want the https proxy", name, env[name])
+		}
+	}
+	if env["no_proxy"] != "10.0.0.1,.svc" {
+		t.Errorf("no_proxy = %q, want the no proxy list", env["no_proxy"])
+	}
+	if _, ok := env["HTTP_PROXY"]; ok {
+		t.Errorf("HTTP_PROXY is set without an http proxy")
+	}
+
+	if len(podSpec.Volumes) != 1 || podSpec.Volumes[0].ConfigMap == nil {
+		t.Fatalf("volumes = %v, want the CA bundle ConfigMap", podSpec.Volumes)
+	}
+	items := podSpec.Volumes[0].ConfigMap.Items
+	if podSpec.Volumes[0].ConfigMap.Name != "internal-ca" || len(items) != 1 || items[0].Key != "bundle.pem" || items[0].Path != "ca.crt" {
+		t.Errorf("CA bundle volume = %+v, want bundle.pem of internal-ca mounted as ca.crt", podSpec.Volumes[0].ConfigMap)
+	}
+	if mounts := podSpec.Containers[0].VolumeMounts; len(mounts) != 1 || mounts[0].MountPath != "/etc/humalect/ca-bundle" {
+		t.Errorf("volume mounts = %v, want the CA bundle in /etc/humalect/ca-bundle", mounts)
+	}
+}
+
+func TestApplyNetworkSettingsWithoutSettings(t *testing.T) {
+	podSpec := corev1.PodSpec{Containers: []corev1.Container{{Name: "agent"}}}
+	ApplyNetworkSettings(&podSpec, &podSpec.Containers[0], netconfig.Settings{})
+	if len(podSpec.Volumes) != 0 || len(podSpec.Containers[0].Env) != 0 || len(podSpec.Containers[0].VolumeMounts) != 0 {
+		t.Errorf("ApplyNetworkSettings() changed the pod without network settings: %+v", podSpec)
+	}
+}
@@ -0,0 +1,182 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	k8sv1 "github.com/Humalect/humalect-core/api/v1"
+	constants "github.com/Humalect/humalect-core/internal/controller/constants"
+	"github.com/Humalect/humalect-core/pkg/webhook"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GetWebhookEvent describes the step a webhook is sent for, notification policies are matched against it.
+func GetWebhookEvent(webhookData WebhookData, success bool, step string, details WebhookStepDetails) k8sv1.WebhookEvent {
+	return k8sv1.WebhookEvent(webhook.NewEvent(webhookData, success, step, details))
+}
+
+// GetNotificationPolicies returns the policies that receive the events of the namespace.
+// Policies from the controller namespace receive the events of every namespace.
+func GetNotificationPolicies(ctx context.Context, c client.Client, namespace string) ([]k8sv1.NotificationPolicy, error) {
+	policies := []k8sv1.NotificationPolicy{}
+	for _, policyNamespace := range []string{constants.ControllerNamespace, namespace} {
+		if policyNamespace == "" {
+			break
+		}
+		policyList := &k8sv1.NotificationPolicyList{}
+		if err := c.List(ctx, policyList, client.InNamespace(policyNamespace)); err != nil {
+			return nil, err
+		}
+		policies = append(policies, policyList.Items...)
+		if namespace == constants.ControllerNamespace {
+			break
+		}
+	}
+	return policies, nil
+}
+
+func MatchesNotificationFilter(filter k8sv1.NotificationFilter, event k8sv1.WebhookEvent) bool {
+	if filter.OnlyFailures && event.Success {
+		return false
+	}
+	if len(filter.Namespaces) > 0 && !containsNamespace(filter.Namespaces, event.Namespace) {
+		return false
+	}
+	if len(filter.Steps) > 0 && !containsStep(filter.Steps, event.Step) {
+		return false
+	}
+	return true
+}
+
+// containsStep also matches hook steps like PRE_DEPLOY_HOOK_EXECUTED:migrate against their step.
+func containsStep(steps []string, step string) bool {
+	for _, item := range steps {
+		if item == step || strings.HasPrefix(step, item+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderNotification returns the body posted to the sink for the event. Webhook sinks get the event as JSON
+// unless they have a template, Slack and Teams sinks get a message with the rendered text.
+func RenderNotification(sink k8sv1.NotificationSink, event k8sv1.WebhookEvent) ([]byte, error) {
+	text := getNotificationText(event)
+	if sink.Template != "" {
+		tmpl, err := template.New(sink.Name).Option("missingkey=zero").Parse(sink.Template)
+		if err != nil {
+			return nil, err
+		}
+		var rendered bytes.Buffer
+		if err := tmpl.Execute(&rendered, event); err != nil {
+			return nil, err
+		}
+		text = rendered.String()
+	}
+
+	switch sink.Type {
+	case constants.NotificationSinkSlack:
+		return json.Marshal(map[string]string{"text": text})
+	case constants.NotificationSinkTeams:
+		themeColor := "2EB886"
+		if !event.Success {
+			themeColor = "D93F0B"
+		}
+		return json.Marshal(map[string]string{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    text,
+			"themeColor": themeColor,
+			"text":       text,
+		})
+	case constants.NotificationSinkWebhook, constants.NotificationSinkNATS, constants.NotificationSinkKafka, "":
+		if sink.Template != "" {
+			return []byte(text), nil
+		}
+		return json.Marshal(event)
+	default:
+		return nil, fmt.Errorf("unknown notification sink type %q", sink.Type)
+	}
+}
+
+func getNotificationText(event k8sv1.WebhookEvent) string {
+	outcome := "succeeded"
+	if !event.Success {
+		outcome = "failed"
+	}
+	text := fmt.Sprintf("Deployment %s: %s %s", event.DeploymentId, event.Step, outcome)
+	if event.Namespace != "" {
+		text = fmt.Sprintf("%s in namespace %s", text, event.Namespace)
+	}
+	if event.Error != "" {
+		text = fmt.Sprintf("%s: %s", text, event.Error)
+	}
+	return text
+}
+
+// NotificationSinkEndpoint is a sink with the values from Secrets resolved.
+type NotificationSinkEndpoint struct {
+	Type    string
+	URL     string
+	Topic   string
+	Headers map[string]string
+}
+
+// GetNotificationSinkEndpoint resolves the URL and headers of a sink, values from Secrets are read from the
+// namespace of the policy. A NotFound error is returned when the policy or the sink no longer exists.
+func GetNotificationSinkEndpoint(ctx context.Context, c client.Client, ref k8sv1.WebhookDeliverySink) (*NotificationSinkEndpoint, error) {
+	policy := &k8sv1.NotificationPolicy{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: ref.PolicyNamespace, Name: ref.PolicyName}, policy); err != nil {
+		return nil, err
+	}
+	var sink *k8sv1.NotificationSink
+	for i := range policy.Spec.Sinks {
+		if policy.Spec.Sinks[i].Name == ref.SinkName {
+			sink = &policy.Spec.Sinks[i]
+		}
+	}
+	if sink == nil {
+		return nil, errors.NewNotFound(schema.GroupResource{Group: k8sv1.GroupVersion.Group, Resource: "notificationpolicies"}, fmt.Sprintf("%s/%s", ref.PolicyName, ref.SinkName))
+	}
+
+	endpoint := &NotificationSinkEndpoint{Type: sink.Type, URL: sink.URL, Topic: sink.Topic, Headers: map[string]string{}}
+	if sink.URLFrom != nil {
+		value, err := getSecretKeyValue(ctx, c, policy.Namespace, *sink.URLFrom)
+		if err != nil {
+			return nil, err
+		}
+		endpoint.URL = value
+	}
+	for _, header := range sink.Headers {
+		value := header.Value
+		if header.ValueFrom != nil {
+			secretValue, err := getSecretKeyValue(ctx, c, policy.Namespace, *header.ValueFrom)
+			if err != nil {
+				return nil, err
+			}
+			value = secretValue
+		}
+		endpoint.Headers[header.Name] = value
+	}
+	return endpoint, nil
+}
+
+func getSecretKeyValue(ctx context.Context, c client.Client, namespace string, selector corev1.SecretKeySelector) (string, error) {
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: selector.Name}, secret); err != nil {
+		// Not a NotFound of the sink, a Secret created after the policy is picked up by the next attempt.
+		return "", fmt.Errorf("failed to read secret %s/%s: %v", namespace, selector.Name, err)
+	}
+	value, ok := secret.Data[selector.Key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found in secret %s/%s", selector.Key, namespace, selector.Name)
+	}
+	return string(value), nil
+}
@@ -0,0 +1,72 @@
+package helpers
+
+import (
+	"encoding/json"
+	"testing"
+
+	k8sv1 "github.com/Humalect/humalect-core/api/v1"
+	constants "github.com/Humalect/humalect-core/internal/controller/constants"
+)
+
+func TestMatchesNotificationFilter(t *testing.T) {
+	failure := k8sv1.WebhookEvent{Namespace: "production", DeploymentId: "dep-1", Step: constants.PostDeployHookExecuted + ":migrate", Success: false}
+	success := k8sv1.WebhookEvent{Namespace: "staging", DeploymentId: "dep-2", Step: constants.DeploymentCompleted, Success: true}
+
+	tests := []struct {
+		name   string
+		filter k8sv1.NotificationFilter
+		event  k8sv1.WebhookEvent
+		want   bool
+	}{
+		{name: "empty filter", filter: k8sv1.NotificationFilter{}, event: success, want: true},
+		{name: "only failures skips success", filter: k8sv1.NotificationFilter{OnlyFailures: true}, event: success, want: false},
+		{name: "only failures", filter: k8sv1.NotificationFilter{OnlyFailures: true}, event: failure, want: true},
+		{name: "other namespace", filter: k8sv1.NotificationFilter{Namespaces: []string{"production"}}, event: success, want: false},
+		{name: "hook step", filter: k8sv1.NotificationFilter{Steps: []string{constants.PostDeployHookExecuted}}, event: failure, want: true},
+		{name: "other step", filter: k8sv1.NotificationFilter{Steps: []string{constants.DeploymentCompleted}}, event: failure, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchesNotificationFilter(tt.filter, tt.event); got != tt.want {
+				t.Fatalf("MatchesNotificationFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderNotification(t *testing.T) {
+	event := k8sv1.WebhookEvent{Namespace: "production", DeploymentId: "dep-1", Step: constants.DeploymentCompleted, Success: false, Error: "rollout timed out"}
+
+	body, err := RenderNotification(k8sv1.NotificationSink{Name: "slack", Type: constants.NotificationSinkSlack, Template: "{{ .Step }} failed in {{ .Namespace }}: {{ .Error }}"}, event)
+	if err != nil {
+		t.Fatalf("RenderNotification() error = %v", err)
+	}
+	if want := `{"text":"DEPLOYMENT_COMPLETED failed in production: rollout timed out"}`; string(body) != want {
+		t.Fatalf("slack body = %s, want %s", body, want)
+	}
+
+	body, err = RenderNotification(k8sv1.NotificationSink{Name: "teams", Type: constants.NotificationSinkTeams}, event)
+	if err != nil {
+		t.Fatalf("RenderNotification() error = %v", err)
+	}
+	var card map[string]string
+	if err := json.Unmarshal(body, &card); err != nil {
+		t.Fatal(err)
+	}
+	if card["@type"] != "MessageCard" || card["text"] != "Deployment dep-1: DEPLOYMENT_COMPLETED failed in namespace production: rollout timed out" {
+		t.Fatalf("unexpected teams card %v", card)
+	}
+
+	body, err = RenderNotification(k8sv1.NotificationSink{Name: "backend", Type: constants.NotificationSinkWebhook}, event)
+	if err != nil {
+		t.Fatalf("RenderNotification() error = %v", err)
+	}
+	var decoded k8sv1.WebhookEvent
+	if err := json.Unmarshal(body, &decoded); err != nil || decoded != event {
+		t.Fatalf("webhook body = %s", body)
+	}
+
+	if _, err := RenderNotification(k8sv1.NotificationSink{Name: "broken", Template: "{{ .Step"}, event); err == nil {
+		t.Fatal("expected an error for an invalid template")
+	}
+}
@@ -0,0 +1,121 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	k8sv1 "github.com/Humalect/humalect-core/api/v1"
+	constants "github.com/Humalect/humalect-core/internal/controller/constants"
+)
+
+// EventPublisher publishes notifications to a message bus. Publish only returns once the bus accepted the
+// message, the delivery reconciler retries failed publishes so events are delivered at least once.
+type EventPublisher interface {
+	Publish(ctx context.Context, topic string, payload []byte, headers map[string]string, key string) error
+	Close() error
+}
+
+// eventPublishers creates the publisher of a sink type from the comma separated servers of the sink.
+var eventPublishers = map[string]func(servers []string) (EventPublisher, error){
+	constants.NotificationSinkNATS:  NewNATSPublisher,
+	constants.NotificationSinkKafka: NewKafkaPublisher,
+}
+
+func IsEventPublisherSink(sinkType string) bool {
+	_, ok := eventPublishers[sinkType]
+	return ok
+}
+
+// EventPublishers keeps one connected publisher per sink so deliveries do not open a connection per event.
+type EventPublishers struct {
+	mutex      sync.Mutex
+	publishers map[k8sv1.WebhookDeliverySink]*sinkPublisher
+}
+
+// sinkPublisher remembers the type and servers the publisher was created for, the publisher is replaced when
+// they change in the NotificationPolicy or its Secret.
+type sinkPublisher struct {
+	sinkType  string
+	servers   string
+	publisher EventPublisher
+}
+
+func NewEventPublishers() *EventPublishers {
+	return &EventPublishers{publishers: map[k8sv1.WebhookDeliverySink]*sinkPublisher{}}
+}
+
+// Publish publishes the payload to the topic of the sink. The idempotency key is used as the message key so
+// consumers can drop messages that were published again after a failed attempt. The publisher of the sink is
+// closed after a failed publish so the next attempt connects again.
+func (p *EventPublishers) Publish(ctx context.Context, sink k8sv1.WebhookDeliverySink, endpoint NotificationSinkEndpoint, payload []byte, idempotencyKey string) error {
+	if endpoint.Topic == "" {
+		return fmt.Errorf("no topic configured for %s sink", endpoint.Type)
+	}
+	publisher, err := p.getPublisher(sink, endpoint)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, constants.EventPublishTimeoutSeconds*time.Second)
+	defer cancel()
+	if err := publisher.Publish(ctx, endpoint.Topic, payload, endpoint.Headers, idempotencyKey); err != nil {
+		p.remove(sink, publisher)
+		return err
+	}
+	return nil
+}
+
+func (p *EventPublishers) getPublisher(sink k8sv1.WebhookDeliverySink, endpoint NotificationSinkEndpoint) (EventPublisher, error) {
+	newPublisher, ok := eventPublishers[endpoint.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown event publisher %q", endpoint.Type)
+	}
+	servers := []string{}
+	for _, server := range strings.Split(endpoint.URL, ",") {
+		if server = strings.TrimSpace(server); server != "" {
+			servers = append(servers, server)
+		}
+	}
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("no servers configured for %s sink", endpoint.Type)
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	current, ok := p.publishers[sink]
+	if ok && current.sinkType == endpoint.Type && current.servers == strings.Join(servers, ",") {
+		return current.publisher, nil
+	}
+	if ok {
+		current.publisher.Close()
+		delete(p.publishers, sink)
+	}
+	publisher, err := newPublisher(servers)
+	if err != nil {
+		return nil, err
+	}
+	p.publishers[sink] = &sinkPublisher{sinkType: endpoint.Type, servers: strings.Join(servers, ","), publisher: publisher}
+	return publisher, nil
+}
+
+func (p *EventPublishers) remove(sink k8sv1.WebhookDeliverySink, publisher EventPublisher) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if current, ok := p.publishers[sink]; ok && current.publisher == publisher {
+		current.publisher.Close()
+		delete(p.publishers, sink)
+	}
+}
+
+// Close closes the publishers of all sinks, it is called when the manager stops.
+func (p *EventPublishers) Close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for sink, current := range p.publishers {
+		current.publisher.Close()
+		delete(p.publishers, sink)
+	}
+}
@@ -0,0 +1,33 @@
+package helpers
+
+import (
+	"context"
+
+	k8sv1 "github.com/Humalect/humalect-core/api/v1"
+	constants "github.com/Humalect/humalect-core/internal/controller/constants"
+	"github.com/Humalect/humalect-core/pkg/webhook"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var webhookDeliveryClient client.Client
+
+// SetWebhookDeliveryClient makes SendWebhook record every webhook as a WebhookDelivery that the delivery
+// reconciler posts until it is acknowledged, instead of posting it right away.
+func SetWebhookDeliveryClient(c client.Client) {
+	webhookDeliveryClient = c
+}
+
+// RecordWebhookDelivery records the webhook of the event. The delivery reconciler posts it to webhookEndpoint,
+// when set, and to every NotificationPolicy sink that matches the event.
+func RecordWebhookDelivery(ctx context.Context, c client.Client, webhookEndpoint string, request webhook.Request, idempotencyKey string, event k8sv1.WebhookEvent) error {
+	delivery, err := webhook.NewDelivery(constants.ControllerNamespace, webhookEndpoint, request, idempotencyKey, webhook.Event(event))
+	if err != nil {
+		return err
+	}
+	err = c.Create(ctx, delivery)
+	if errors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,62 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+
+	k8sv1 "github.com/Humalect/humalect-core/api/v1"
+	"github.com/Humalect/humalect-core/pkg/redact"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+type redactorContextKey struct{}
+
+// GetApplicationRedactor returns a redactor for the credentials in the spec of the Application, the
+// secret values are added when they are fetched.
+func GetApplicationRedactor(application *k8sv1.Application) *redact.Redactor {
+	return redact.NewRedactor(
+		application.Spec.AwsSecretCredentials.AccessKey,
+		application.Spec.AwsSecretCredentials.SecretKey,
+		application.Spec.AzureVaultCredentials.Token,
+	)
+}
+
+// GetDeploymentSetRedactor returns a redactor for the credentials in the spec of the DeploymentSet.
+func GetDeploymentSetRedactor(deploymentSet *k8sv1.DeploymentSet) *redact.Redactor {
+	return redact.NewRedactor(
+		deploymentSet.Spec.SourceCodeToken,
+		deploymentSet.Spec.EcrCredentials.AccessKey,
+		deploymentSet.Spec.EcrCredentials.SecretKey,
+		deploymentSet.Spec.AcrCredentials.ManagementScopeToken,
+		deploymentSet.Spec.AwsSecretCredentials.AccessKey,
+		deploymentSet.Spec.AwsSecretCredentials.SecretKey,
+		deploymentSet.Spec.AzureVaultCredentials.Token,
+	)
+}
+
+// ContextWithRedactor passes the redactor along the reconcile, it scrubs the credentials and fetched secret
+// values from the webhooks, status messages and Events of the reconcile.
+func ContextWithRedactor(ctx context.Context, redactor *redact.Redactor) context.Context {
+	return context.WithValue(ctx, redactorContextKey{}, redactor)
+}
+
+// RedactorFromContext returns the redactor of the reconcile, a nil redactor leaves text as it is.
+func RedactorFromContext(ctx context.Context) *redact.Redactor {
+	redactor, _ := ctx.Value(redactorContextKey{}).(*redact.Redactor)
+	return redactor
+}
+
+// RedactDetails redacts the free text of the outcome of a step with the redactor of the context.
+func RedactDetails(ctx context.Context, details WebhookStepDetails) WebhookStepDetails {
+	redactor := RedactorFromContext(ctx)
+	details.Error = redactor.String(details.Error)
+	details.Reason = redactor.String(details.Reason)
+	details.LogExcerpt = redactor.String(details.LogExcerpt)
+	return details
+}
+
+// RecordEvent emits an Event with the secret values known to the redactor of the context scrubbed.
+func RecordEvent(ctx context.Context, recorder record.EventRecorder, object runtime.Object, eventType string, reason string, messageFmt string, args ...interface{}) {
+	recorder.Event(object, eventType, reason, RedactorFromContext(ctx).String(fmt.Sprintf(messageFmt, args...)))
+}
@@ -0,0 +1,27 @@
+package helpers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Humalect/humalect-core/pkg/redact"
+)
+
+func TestRedactDetails(t *testing.T) {
+	ctx := ContextWithRedactor(context.Background(), redact.NewRedactor("ghp_token123"))
+	details := RedactDetails(ctx, WebhookStepDetails{Error: "bad ghp_token123", LogExcerpt: "token=ghp_token123"})
+	if details.Error != "bad [REDACTED]" || details.LogExcerpt != "token=[REDACTED]" {
+		t.Fatalf("RedactDetails() = %+v", details)
+	}
+}
+
+func TestRedactorFromContext(t *testing.T) {
+	if got := RedactorFromContext(context.Background()).String("ghp_token123"); got != "ghp_token123" {
+		t.Fatalf("a missing redactor should leave the text as it is, got %q", got)
+	}
+	ctx := ContextWithRedactor(context.Background(), redact.NewRedactor("ghp_token123"))
+	RedactorFromContext(ctx).Add("s3cr3t-value")
+	if got := RedactorFromContext(ctx).String("ghp_token123 s3cr3t-value"); got != "[REDACTED] [REDACTED]" {
+		t.Fatalf("String() = %q", got)
+	}
+}
@@ -0,0 +1,119 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	k8sv1 "github.com/Humalect/humalect-core/api/v1"
+	constants "github.com/Humalect/humalect-core/internal/controller/constants"
+)
+
+// GetSmokeTestURL returns the URL the smoke test calls, either the cluster DNS name of the Service or the
+// first host of the Ingress.
+func GetSmokeTestURL(application *k8sv1.Application, smokeTest k8sv1.SmokeTestSpec) (string, error) {
+	path := smokeTest.Path
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	if smokeTest.Target == constants.SmokeTestTargetIngress {
+		ingress := application.Spec.IngressYamlManifest
+		for _, rule := range ingress.Spec.Rules {
+			if rule.Host == "" {
+				continue
+			}
+			scheme := "http"
+			if len(ingress.Spec.TLS) > 0 {
+				scheme = "https"
+			}
+			return fmt.Sprintf("%s://%s%s", scheme, rule.Host, path), nil
+		}
+		return "", fmt.Errorf("ingress %s has no host to run the smoke test against", ingress.Metadata.Name)
+	}
+
+	service := application.Spec.ServiceYamlManifest
+	if len(service.Spec.Ports) == 0 {
+		return "", fmt.Errorf("service %s has no port to run the smoke test against", service.Metadata.Name)
+	}
+	return fmt.Sprintf("http://%s.%s.svc.cluster.local:%d%s", service.Metadata.Name, application.GetNamespace(), service.Spec.Ports[0].Port, path), nil
+}
+
+// GetSmokeTestRetryInterval returns how long to wait between two attempts of the smoke test.
+func GetSmokeTestRetryInterval(smokeTest k8sv1.SmokeTestSpec) time.Duration {
+	if smokeTest.RetryIntervalSeconds == 0 {
+		return constants.DefaultSmokeTestRetryIntervalSecs * time.Second
+	}
+	return time.Duration(smokeTest.RetryIntervalSeconds) * time.Second
+}
+
+// GetSmokeTestWait returns the attempts of the smoke test for the generation and how long to wait before the
+// next attempt. Attempts of an older generation are dropped.
+func GetSmokeTestWait(status *k8sv1.SmokeTestStatus, generation int64, smokeTest k8sv1.SmokeTestSpec, now time.Time) (k8sv1.SmokeTestStatus, time.Duration) {
+	if status == nil || status.ObservedGeneration != generation {
+		return k8sv1.SmokeTestStatus{ObservedGeneration: generation}, 0
+	}
+	if status.LastAttemptTime == nil {
+		return *status, 0
+	}
+	wait := status.LastAttemptTime.Add(GetSmokeTestRetryInterval(smokeTest)).Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	return *status, wait
+}
+
+// RunSmokeTest calls url once and checks that the response has the expected status and body. Retries are
+// left to the caller so that no reconcile waits for them.
+func RunSmokeTest(ctx context.Context, url string, smokeTest k8sv1.SmokeTestSpec) error {
+	expectedStatus := smokeTest.ExpectedStatus
+	if expectedStatus == 0 {
+		expectedStatus = http.StatusOK
+	}
+	timeout := time.Duration(smokeTest.TimeoutSeconds) * time.Second
+	if smokeTest.TimeoutSeconds == 0 {
+		timeout = constants.DefaultSmokeTestTimeoutSeconds * time.Second
+	}
+	var bodyRegex *regexp.Regexp
+	if smokeTest.BodyRegex != "" {
+		regex, err := regexp.Compile(smokeTest.BodyRegex)
+		if err != nil {
+			return fmt.Errorf("invalid smoke test body regex %q: %v", smokeTest.BodyRegex, err)
+		}
+		bodyRegex = regex
+	}
+
+	client := &http.Client{Timeout: timeout}
+	return checkSmokeTestResponse(ctx, client, url, expectedStatus, bodyRegex)
+}
+
+func checkSmokeTestResponse(ctx context.Context, client *http.Client, url string, expectedStatus int, bodyRegex *regexp.Regexp) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expectedStatus {
+		return fmt.Errorf("expected status %d, got %d", expectedStatus, resp.StatusCode)
+	}
+	if bodyRegex == nil {
+		return nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if !bodyRegex.Match(body) {
+		return fmt.Errorf("response body does not match %q", bodyRegex.String())
+	}
+	return nil
+}
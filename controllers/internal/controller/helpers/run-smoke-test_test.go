@@ -0,0 +1,78 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	k8sv1 "github.com/Humalect/humalect-core/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRunSmokeTest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/healthz":
+			fmt.Fprint(w, `{"status":"ok"}`)
+		case "/down":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		path      string
+		smokeTest k8sv1.SmokeTestSpec
+		wantErr   bool
+	}{
+		{name: "expected status", path: "/healthz"},
+		{name: "body matches", path: "/healthz", smokeTest: k8sv1.SmokeTestSpec{BodyRegex: `"status":\s*"ok"`}},
+		{name: "body does not match", path: "/healthz", smokeTest: k8sv1.SmokeTestSpec{BodyRegex: "degraded"}, wantErr: true},
+		{name: "unexpected status", path: "/down", wantErr: true},
+		{name: "custom expected status", path: "/missing", smokeTest: k8sv1.SmokeTestSpec{ExpectedStatus: http.StatusNotFound}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RunSmokeTest(context.Background(), server.URL+tt.path, tt.smokeTest)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RunSmokeTest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetSmokeTestWait(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	lastAttempt := metav1.NewTime(now.Add(-2 * time.Second))
+	smokeTest := k8sv1.SmokeTestSpec{Retries: 2, RetryIntervalSeconds: 5}
+
+	tests := []struct {
+		name         string
+		status       *k8sv1.SmokeTestStatus
+		now          time.Time
+		wantAttempts int
+		wantWait     time.Duration
+	}{
+		{name: "first attempt", now: now, wantAttempts: 0, wantWait: 0},
+		{name: "retry interval not over", status: &k8sv1.SmokeTestStatus{ObservedGeneration: 3, Attempts: 1, LastAttemptTime: &lastAttempt}, now: now, wantAttempts: 1, wantWait: 3 * time.Second},
+		{name: "retry interval over", status: &k8sv1.SmokeTestStatus{ObservedGeneration: 3, Attempts: 1, LastAttemptTime: &lastAttempt}, now: now.Add(10 * time.Second), wantAttempts: 1, wantWait: 0},
+		{name: "attempts of an older generation", status: &k8sv1.SmokeTestStatus{ObservedGeneration: 2, Attempts: 3, LastAttemptTime: &lastAttempt}, now: now, wantAttempts: 0, wantWait: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, wait := GetSmokeTestWait(tt.status, 3, smokeTest, tt.now)
+			if status.ObservedGeneration != 3 || status.Attempts != tt.wantAttempts {
+				t.Errorf("GetSmokeTestWait() status = %+v, want %d attempts for generation 3", status, tt.wantAttempts)
+			}
+			if wait != tt.wantWait {
+				t.Errorf("GetSmokeTestWait() wait = %s, want %s", wait, tt.wantWait)
+			}
+		})
+	}
+}
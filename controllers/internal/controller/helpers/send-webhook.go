@@ -1,53 +1,28 @@
 package helpers
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
+	"time"
 
+	k8sv1 "github.com/Humalect/humalect-core/api/v1"
 	constants "github.com/Humalect/humalect-core/internal/controller/constants"
+	"github.com/Humalect/humalect-core/internal/controller/metrics"
+	"github.com/Humalect/humalect-core/pkg/tracing"
+	"github.com/Humalect/humalect-core/pkg/webhook"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-func SendWebhookRequest(webhookEndpoint string, data map[string]interface{}) (response *WebhookResponse, err error) {
-	apiURL := webhookEndpoint
-
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := http.Post(apiURL, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		return &WebhookResponse{
-			Success: true,
-			Status:  resp.StatusCode,
-			Data:    body,
-		}, nil
-	}
-
-	return &WebhookResponse{
-		Success: false,
-		Status:  resp.StatusCode,
-		Data:    body,
-	}, nil
+func SendWebhookRequest(webhookEndpoint string, request webhook.Request, idempotencyKey string) (response *webhook.Response, err error) {
+	return getDefaultWebhookClient().Send(webhookEndpoint, request, idempotencyKey)
 }
 
-func CreateSendWebhookRequest(webhookEndpoint string, data map[string]interface{}) (response *WebhookResponse, err error) {
-	response, err = SendWebhookRequest(webhookEndpoint, data)
+func CreateSendWebhookRequest(webhookEndpoint string, request webhook.Request, idempotencyKey string) (response *webhook.Response, err error) {
+	response, err = SendWebhookRequest(webhookEndpoint, request, idempotencyKey)
 	if err != nil {
 		fmt.Printf("Error Sending Webhook: %v\n", err)
+		return response, err
 	}
 
 	if response.Success {
@@ -59,37 +34,74 @@ func CreateSendWebhookRequest(webhookEndpoint string, data map[string]interface{
 	return response, err
 }
 
+// RecordDeploymentMetrics counts the event of a step in the deployment metrics without sending it.
+func RecordDeploymentMetrics(data string, success bool, step string, details WebhookStepDetails) {
+	var webhookData WebhookData
+	if err := json.Unmarshal([]byte(data), &webhookData); err != nil {
+		fmt.Println("Some error occured while parsing webhook data:= ", err)
+		return
+	}
+	RecordDeploymentEvent(GetWebhookEvent(webhookData, success, step, details))
+}
+
+// RecordDeploymentEvent counts the event in the deployment metrics and ends the root span of the deployment
+// at its terminal event. The span started when the deployment did, ElapsedSeconds before the event.
+func RecordDeploymentEvent(event k8sv1.WebhookEvent) {
+	if !metrics.RecordDeploymentEvent(event) {
+		return
+	}
+	var err error
+	if !event.Success {
+		err = fmt.Errorf("deployment failed at %s: %s", event.Step, event.Error)
+	}
+	tracing.EndDeploymentSpan(event.DeploymentId, time.Now().Add(-time.Duration(event.ElapsedSeconds)*time.Second), err,
+		attribute.String("humalect.deployment_id", event.DeploymentId),
+		attribute.String("humalect.pipeline_id", event.PipelineId),
+		attribute.String("humalect.namespace", event.Namespace),
+		attribute.String("humalect.managed_by", event.ManagedBy),
+		attribute.String("humalect.step", event.Step),
+	)
+}
+
 func SendWebhook(WebhookEndpoint string, data string, success bool, step string) {
-	if len(WebhookEndpoint) > 0 {
-		var WebhookData map[string]interface{}
-		err := json.Unmarshal([]byte(data), &WebhookData)
-		if err != nil {
-			fmt.Println("Some error occured while parsing webhook data:= ", err)
-		}
+	SendWebhookWithDetails(WebhookEndpoint, data, success, step, WebhookStepDetails{})
+}
 
-		var strStatus string
-		fmt.Println("Received Success")
-		fmt.Println(success)
-		if success {
-			strStatus = step
-		} else {
-			strStatus = constants.DeploymentFailed
+// SendWebhookWithDetails sends the webhook of a step together with its error, reason and duration.
+// Without a webhook endpoint the event is still recorded for the notification policies.
+func SendWebhookWithDetails(WebhookEndpoint string, data string, success bool, step string, details WebhookStepDetails) {
+	if len(WebhookEndpoint) == 0 && webhookDeliveryClient == nil {
+		return
+	}
+	var webhookData WebhookData
+	err := json.Unmarshal([]byte(data), &webhookData)
+	if err != nil {
+		fmt.Println("Some error occured while parsing webhook data:= ", err)
+	}
+	if len(WebhookEndpoint) == 0 && webhookData.DeploymentId == "" {
+		return
+	}
+
+	fmt.Println("Received Success")
+	fmt.Println(success)
+	request, err := webhook.BuildRequest(webhookData, success, step, details, constants.CloudEventSourceController)
+	if err != nil {
+		fmt.Println("Some error occured while building webhook payload:= ", err)
+		return
+	}
+	idempotencyKey := webhook.IdempotencyKey(webhookData.DeploymentId, step)
+	if webhookDeliveryClient != nil {
+		event := GetWebhookEvent(webhookData, success, step, details)
+		err := RecordWebhookDelivery(context.TODO(), webhookDeliveryClient, WebhookEndpoint, request, idempotencyKey, event)
+		if err == nil {
+			return
 		}
-		webhookData := map[string]interface{}{
-			"type": constants.WebhookTypeDeploymentStatusUpdate,
-			"data": map[string]interface{}{
-				"queueName":  WebhookData["queueName"],
-				"statusData": WebhookData["statusData"],
-				"state":      WebhookData["state"],
-				"status":     strStatus,
-			},
+		// The delivery reconciler never sees the event, its metrics are counted here.
+		RecordDeploymentEvent(event)
+		if len(WebhookEndpoint) == 0 {
+			return
 		}
-		CreateSendWebhookRequest(WebhookEndpoint, webhookData)
+		fmt.Println("Error recording webhook delivery, sending it right away: ", err)
 	}
-}
-
-type WebhookResponse struct {
-	Success bool
-	Status  int
-	Data    []byte
+	CreateSendWebhookRequest(WebhookEndpoint, request, idempotencyKey)
 }
@@ -21,3 +21,36 @@ func UpdateStatusData(webhookDataString string, step string, success bool) strin
 	jsonData, err := json.Marshal(WebhookData)
 	return string(jsonData)
 }
+
+func UpdateWebhookDataField(webhookDataString string, key string, value interface{}) string {
+	var WebhookData map[string]interface{}
+	err := json.Unmarshal([]byte(webhookDataString), &WebhookData)
+	if err != nil {
+		fmt.Println("Some error occured while parsing webhook data:= ", err)
+	}
+	if WebhookData == nil {
+		WebhookData = map[string]interface{}{}
+	}
+	WebhookData[key] = value
+	jsonData, err := json.Marshal(WebhookData)
+	return string(jsonData)
+}
+
+// UpdateWebhookDataId sets an id of the deployment, an empty value keeps the id the webhook data already has.
+func UpdateWebhookDataId(webhookDataString string, key string, id string) string {
+	if id == "" {
+		return webhookDataString
+	}
+	return UpdateWebhookDataField(webhookDataString, key, id)
+}
+
+// UpdateWebhookDataFormat selects the payload format used by SendWebhook, empty values keep the current one.
+func UpdateWebhookDataFormat(webhookDataString string, format string, contentMode string) string {
+	if format != "" {
+		webhookDataString = UpdateWebhookDataField(webhookDataString, "webhookFormat", format)
+	}
+	if contentMode != "" {
+		webhookDataString = UpdateWebhookDataField(webhookDataString, "webhookContentMode", contentMode)
+	}
+	return webhookDataString
+}
@@ -0,0 +1,31 @@
+package helpers
+
+import (
+	"sync"
+
+	constants "github.com/Humalect/humalect-core/internal/controller/constants"
+	"github.com/Humalect/humalect-core/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// NewWebhookClient returns a client that signs with the secret from the webhook signing Secret in the
+// controller namespace.
+func NewWebhookClient() *webhook.Client {
+	logger := log.Log.WithName("webhook")
+	signingSecret := webhook.NewSigningSecretLoader(webhook.GetSigningSecretRef(constants.ControllerNamespace), GetK8sConfig, logger.Info)
+	client := webhook.NewClient(signingSecret)
+	client.Log = logger.Info
+	return client
+}
+
+var (
+	defaultWebhookClient     *webhook.Client
+	defaultWebhookClientOnce sync.Once
+)
+
+func getDefaultWebhookClient() *webhook.Client {
+	defaultWebhookClientOnce.Do(func() {
+		defaultWebhookClient = NewWebhookClient()
+	})
+	return defaultWebhookClient
+}
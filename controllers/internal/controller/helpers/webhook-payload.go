@@ -0,0 +1,9 @@
+package helpers
+
+import "github.com/Humalect/humalect-core/pkg/webhook"
+
+// The webhook payload is shared with the agent, it is built by webhook.BuildRequest.
+type (
+	WebhookData        = webhook.Data
+	WebhookStepDetails = webhook.StepDetails
+)
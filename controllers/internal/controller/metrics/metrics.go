@@ -0,0 +1,135 @@
+// Package metrics defines the Prometheus metrics of the controller. They are registered with the
+// controller-runtime registry and served on the manager metrics endpoint.
+package metrics
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	k8sv1 "github.com/Humalect/humalect-core/api/v1"
+	constants "github.com/Humalect/humalect-core/internal/controller/constants"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+)
+
+var (
+	DeploymentsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "humalect_deployments_total",
+		Help: "Deployments by outcome, failed_step is the step that reported the failure.",
+	}, []string{"namespace", "managed_by", "outcome", "failed_step"})
+
+	BuildDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "humalect_build_duration_seconds",
+		Help:    "Duration of the kaniko image builds.",
+		Buckets: []float64{30, 60, 120, 300, 600, 900, 1200, 1800, 3600},
+	}, []string{"namespace", "managed_by", "outcome"})
+
+	DeploymentLeadTimeSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "humalect_deployment_lead_time_seconds",
+		Help:    "Time from the creation of the DeploymentSet until the rollout completed.",
+		Buckets: []float64{60, 120, 300, 600, 900, 1200, 1800, 3600, 7200},
+	}, []string{"namespace", "managed_by"})
+
+	SecretFetchDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "humalect_secret_fetch_duration_seconds",
+		Help:    "Latency of fetching application secrets from the secrets provider.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"namespace", "managed_by", "provider"})
+
+	SecretFetchErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "humalect_secret_fetch_errors_total",
+		Help: "Failed fetches of application secrets from the secrets provider.",
+	}, []string{"namespace", "managed_by", "provider"})
+
+	WebhookDeliveryAttemptsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "humalect_webhook_delivery_attempts_total",
+		Help: "Webhook and notification delivery attempts by sink type.",
+	}, []string{"namespace", "managed_by", "sink_type"})
+
+	WebhookDeliveryFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "humalect_webhook_delivery_failures_total",
+		Help: "Failed webhook and notification delivery attempts by sink type.",
+	}, []string{"namespace", "managed_by", "sink_type"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		DeploymentsTotal,
+		BuildDurationSeconds,
+		DeploymentLeadTimeSeconds,
+		SecretFetchDurationSeconds,
+		SecretFetchErrorsTotal,
+		WebhookDeliveryAttemptsTotal,
+		WebhookDeliveryFailuresTotal,
+	)
+}
+
+// RecordSecretFetch records the latency of a secret fetch and counts it as an error when it failed.
+func RecordSecretFetch(namespace string, managedBy string, provider string, start time.Time, err error) {
+	SecretFetchDurationSeconds.WithLabelValues(namespace, managedBy, provider).Observe(time.Since(start).Seconds())
+	if err != nil {
+		SecretFetchErrorsTotal.WithLabelValues(namespace, managedBy, provider).Inc()
+	}
+}
+
+// countedDeployments remembers the deployments whose outcome was counted. The terminal event of a deployment
+// can be seen twice, when it was sent directly and recorded later, and is kept for as long as its delivery.
+var (
+	countedDeployments      = map[string]time.Time{}
+	countedDeploymentsMutex sync.Mutex
+)
+
+// RecordDeploymentEvent counts the outcome of a deployment at its terminal event, once per deploymentId, and
+// observes the duration of the kaniko build. It reports whether the event ended the deployment.
+func RecordDeploymentEvent(event k8sv1.WebhookEvent) bool {
+	if event.Step == constants.KanikoJobExecuted && event.DurationSeconds > 0 {
+		outcome := OutcomeSucceeded
+		if !event.Success {
+			outcome = OutcomeFailed
+		}
+		BuildDurationSeconds.WithLabelValues(event.Namespace, event.ManagedBy, outcome).Observe(float64(event.DurationSeconds))
+	}
+	if !event.Terminal || !markDeploymentCounted(event.DeploymentId, time.Now()) {
+		return false
+	}
+	if !event.Success {
+		DeploymentsTotal.WithLabelValues(event.Namespace, event.ManagedBy, OutcomeFailed, GetStepLabel(event.Step)).Inc()
+		return true
+	}
+	DeploymentsTotal.WithLabelValues(event.Namespace, event.ManagedBy, OutcomeSucceeded, "").Inc()
+	if event.ElapsedSeconds > 0 {
+		DeploymentLeadTimeSeconds.WithLabelValues(event.Namespace, event.ManagedBy).Observe(float64(event.ElapsedSeconds))
+	}
+	return true
+}
+
+// markDeploymentCounted reports false when the outcome of the deployment was counted already.
+func markDeploymentCounted(deploymentId string, now time.Time) bool {
+	if deploymentId == "" {
+		return true
+	}
+	countedDeploymentsMutex.Lock()
+	defer countedDeploymentsMutex.Unlock()
+	for id, countedAt := range countedDeployments {
+		if now.Sub(countedAt) > constants.WebhookDeliveryMaxAgeSeconds*time.Second {
+			delete(countedDeployments, id)
+		}
+	}
+	if _, ok := countedDeployments[deploymentId]; ok {
+		return false
+	}
+	countedDeployments[deploymentId] = now
+	return true
+}
+
+// GetStepLabel drops the hook name from hook steps like PRE_DEPLOY_HOOK_EXECUTED:migrate to keep the
+// number of label values bounded.
+func GetStepLabel(step string) string {
+	return strings.SplitN(step, ":", 2)[0]
+}
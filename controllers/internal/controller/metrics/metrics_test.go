@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	k8sv1 "github.com/Humalect/humalect-core/api/v1"
+	constants "github.com/Humalect/humalect-core/internal/controller/constants"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecordSecretFetch(t *testing.T) {
+	RecordSecretFetch("production", "humalect", "aws", time.Now(), nil)
+	RecordSecretFetch("production", "humalect", "aws", time.Now(), errors.New("access denied"))
+
+	if got := testutil.ToFloat64(SecretFetchErrorsTotal.WithLabelValues("production", "humalect", "aws")); got != 1 {
+		t.Fatalf("secret fetch errors = %v, want 1", got)
+	}
+	if got := testutil.CollectAndCount(SecretFetchDurationSeconds); got != 1 {
+		t.Fatalf("secret fetch duration series = %v, want 1", got)
+	}
+}
+
+func TestGetStepLabel(t *testing.T) {
+	if got := GetStepLabel("PRE_DEPLOY_HOOK_EXECUTED:migrate"); got != "PRE_DEPLOY_HOOK_EXECUTED" {
+		t.Fatalf("GetStepLabel() = %q", got)
+	}
+	if got := GetStepLabel("DEPLOYMENT_COMPLETED"); got != "DEPLOYMENT_COMPLETED" {
+		t.Fatalf("GetStepLabel() = %q", got)
+	}
+}
+
+func TestRecordDeploymentEventCountsOutcomeOnce(t *testing.T) {
+	events := []k8sv1.WebhookEvent{
+		// A failed step that is retried does not end the deployment.
+		{Namespace: "staging", ManagedBy: "humalect", DeploymentId: "dep-1", Step: constants.CreatedKubernetesResources},
+		{Namespace: "staging", ManagedBy: "humalect", DeploymentId: "dep-1", Step: constants.DeploymentCompleted, Success: true, Terminal: true},
+		// The same terminal event recorded again after it was sent directly.
+		{Namespace: "staging", ManagedBy: "humalect", DeploymentId: "dep-1", Step: constants.DeploymentCompleted, Success: true, Terminal: true},
+		{Namespace: "staging", ManagedBy: "humalect", DeploymentId: "dep-2", Step: constants.KanikoJobExecuted, Terminal: true, DurationSeconds: 90},
+		{Namespace: "staging", ManagedBy: "humalect", DeploymentId: "dep-2", Step: constants.KanikoJobExecuted, Terminal: true, DurationSeconds: 90},
+	}
+	ended := 0
+	for _, event := range events {
+		if RecordDeploymentEvent(event) {
+			ended++
+		}
+	}
+	if ended != 2 {
+		t.Errorf("%d events ended a deployment, want 2", ended)
+	}
+
+	if got := testutil.ToFloat64(DeploymentsTotal.WithLabelValues("staging", "humalect", OutcomeSucceeded, "")); got != 1 {
+		t.Errorf("succeeded deployments = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(DeploymentsTotal.WithLabelValues("staging", "humalect", OutcomeFailed, constants.KanikoJobExecuted)); got != 1 {
+		t.Errorf("failed deployments = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(DeploymentsTotal.WithLabelValues("staging", "humalect", OutcomeFailed, constants.CreatedKubernetesResources)); got != 0 {
+		t.Errorf("failed deployments at a retried step = %v, want 0", got)
+	}
+}
@@ -0,0 +1,268 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	k8sv1 "github.com/Humalect/humalect-core/api/v1"
+	constants "github.com/Humalect/humalect-core/internal/controller/constants"
+	helpers "github.com/Humalect/humalect-core/internal/controller/helpers"
+	"github.com/Humalect/humalect-core/internal/controller/metrics"
+	"github.com/Humalect/humalect-core/pkg/webhook"
+)
+
+// WebhookDeliveryReconciler posts recorded webhooks until the endpoint acknowledges them
+type WebhookDeliveryReconciler struct {
+	client.Client
+	Scheme          *runtime.Scheme
+	WebhookClient   *webhook.Client
+	EventPublishers *helpers.EventPublishers
+}
+
+//+kubebuilder:rbac:groups=k8s.humalect.com,resources=webhookdeliveries,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=k8s.humalect.com,resources=webhookdeliveries/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=k8s.humalect.com,resources=notificationpolicies,verbs=get;list;watch
+
+// Reconcile makes one delivery attempt per call. Failed attempts are retried with an exponential backoff
+// until the delivery is acknowledged, rejected with a non retryable status or too old. Finished deliveries
+// are kept for a day so they can be inspected. Before the first attempt, deliveries of an event are fanned out
+// to one delivery per matching NotificationPolicy sink.
+func (r *WebhookDeliveryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	delivery := &k8sv1.WebhookDelivery{}
+	if err := r.Get(ctx, req.NamespacedName, delivery); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if delivery.Status.State == constants.WebhookDeliveryStateDelivered || delivery.Status.State == constants.WebhookDeliveryStateFailed {
+		expiresIn := constants.WebhookDeliveryRetentionSeconds*time.Second - time.Since(delivery.CreationTimestamp.Time)
+		if expiresIn > 0 {
+			return ctrl.Result{RequeueAfter: expiresIn}, nil
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(r.Delete(ctx, delivery))
+	}
+
+	if delivery.Status.LastAttemptTime != nil {
+		waitFor := getWebhookDeliveryBackoff(delivery.Status.Attempts) - time.Since(delivery.Status.LastAttemptTime.Time)
+		if waitFor > 0 {
+			return ctrl.Result{RequeueAfter: waitFor}, nil
+		}
+	}
+
+	if delivery.Spec.Event != nil && delivery.Spec.Sink == nil && delivery.Status.Attempts == 0 {
+		if err := r.fanOutDelivery(ctx, delivery); err != nil {
+			log.Error(err, fmt.Sprintf("log for <depid:%s> ERROR: Failed to fan out webhook delivery %s to notification policies, %v", delivery.Spec.DeploymentId, delivery.GetName(), err))
+			return ctrl.Result{}, err
+		}
+		// The steps of the agent and the controller are recorded as deliveries, so deployment metrics are
+		// counted here rather than in the process that ran the step.
+		helpers.RecordDeploymentEvent(*delivery.Spec.Event)
+	}
+	if delivery.Spec.Endpoint == "" && delivery.Spec.Sink == nil {
+		// Recorded only for the notification policies.
+		now := metav1.Now()
+		delivery.Status.State = constants.WebhookDeliveryStateDelivered
+		delivery.Status.DeliveredTime = &now
+		if err := r.Status().Update(ctx, delivery); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: constants.WebhookDeliveryRetentionSeconds * time.Second}, nil
+	}
+
+	response, err := r.sendDelivery(ctx, delivery)
+	recordDeliveryMetrics(delivery, err == nil && response.Success)
+	now := metav1.Now()
+	delivery.Status.Attempts++
+	delivery.Status.LastAttemptTime = &now
+	delivery.Status.State = constants.WebhookDeliveryStatePending
+	delivery.Status.LastError = ""
+	if err != nil {
+		delivery.Status.LastError = err.Error()
+	} else {
+		delivery.Status.LastResponseStatus = response.Status
+		if !response.Success {
+			delivery.Status.LastError = fmt.Sprintf("endpoint responded with status %d", response.Status)
+		}
+	}
+
+	switch {
+	case err == nil && response.Success:
+		delivery.Status.State = constants.WebhookDeliveryStateDelivered
+		delivery.Status.DeliveredTime = &now
+	case err == nil && !webhook.IsRetryableStatus(response.Status):
+		delivery.Status.State = constants.WebhookDeliveryStateFailed
+	case errors.IsNotFound(err):
+		// The notification policy or its sink was removed.
+		delivery.Status.State = constants.WebhookDeliveryStateFailed
+	case time.Since(delivery.CreationTimestamp.Time) > constants.WebhookDeliveryMaxAgeSeconds*time.Second:
+		delivery.Status.State = constants.WebhookDeliveryStateFailed
+	}
+	if delivery.Status.State != constants.WebhookDeliveryStateDelivered {
+		log.Info(fmt.Sprintf("log for <depid:%s> Webhook delivery %s of step %s failed, %s", delivery.Spec.DeploymentId, delivery.GetName(), delivery.Spec.Step, delivery.Status.LastError))
+	}
+
+	if err := r.Status().Update(ctx, delivery); err != nil {
+		return ctrl.Result{}, err
+	}
+	if delivery.Status.State == constants.WebhookDeliveryStatePending {
+		return ctrl.Result{RequeueAfter: getWebhookDeliveryBackoff(delivery.Status.Attempts)}, nil
+	}
+	return ctrl.Result{RequeueAfter: constants.WebhookDeliveryRetentionSeconds * time.Second}, nil
+}
+
+// fanOutDelivery records a delivery for every NotificationPolicy sink whose filter matches the event.
+// Names are derived from the sink and the payload so fanning out again does not duplicate deliveries.
+func (r *WebhookDeliveryReconciler) fanOutDelivery(ctx context.Context, delivery *k8sv1.WebhookDelivery) error {
+	log := log.FromContext(ctx)
+
+	event := *delivery.Spec.Event
+	policies, err := helpers.GetNotificationPolicies(ctx, r.Client, event.Namespace)
+	if err != nil {
+		return err
+	}
+	for _, policy := range policies {
+		for _, sink := range policy.Spec.Sinks {
+			if !helpers.MatchesNotificationFilter(sink.Filter, event) {
+				continue
+			}
+			payload, err := helpers.RenderNotification(sink, event)
+			if err != nil {
+				log.Error(err, fmt.Sprintf("log for <depid:%s> ERROR: Failed to render notification for sink %s of policy %s/%s, %v", event.DeploymentId, sink.Name, policy.Namespace, policy.Name, err))
+				continue
+			}
+			sinkDelivery := &k8sv1.WebhookDelivery{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      webhook.GetDeliveryName(fmt.Sprintf("%s/%s/%s/%s", delivery.Spec.IdempotencyKey, policy.Namespace, policy.Name, sink.Name), payload),
+					Namespace: delivery.GetNamespace(),
+					Labels:    delivery.GetLabels(),
+				},
+				Spec: k8sv1.WebhookDeliverySpec{
+					Payload:        string(payload),
+					IdempotencyKey: delivery.Spec.IdempotencyKey,
+					DeploymentId:   delivery.Spec.DeploymentId,
+					Step:           delivery.Spec.Step,
+					Event:          delivery.Spec.Event,
+					Sink: &k8sv1.WebhookDeliverySink{
+						PolicyNamespace: policy.Namespace,
+						PolicyName:      policy.Name,
+						SinkName:        sink.Name,
+						Type:            sink.Type,
+					},
+				},
+			}
+			if err := controllerutil.SetOwnerReference(delivery, sinkDelivery, r.Scheme); err != nil {
+				return err
+			}
+			if err := r.Create(ctx, sinkDelivery); err != nil && !errors.IsAlreadyExists(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sendDelivery posts the delivery, or publishes it for message bus sinks. The endpoint and headers of sink
+// deliveries are looked up on every attempt so rotated Secrets are used.
+func (r *WebhookDeliveryReconciler) sendDelivery(ctx context.Context, delivery *k8sv1.WebhookDelivery) (*webhook.Response, error) {
+	request := webhook.Request{Payload: []byte(delivery.Spec.Payload), Headers: delivery.Spec.Headers}
+	if delivery.Spec.Sink == nil {
+		return r.WebhookClient.Send(delivery.Spec.Endpoint, request, delivery.Spec.IdempotencyKey)
+	}
+	endpoint, err := helpers.GetNotificationSinkEndpoint(ctx, r.Client, *delivery.Spec.Sink)
+	if err != nil {
+		return nil, err
+	}
+	if helpers.IsEventPublisherSink(endpoint.Type) {
+		if err := r.EventPublishers.Publish(ctx, *delivery.Spec.Sink, *endpoint, request.Payload, delivery.Spec.IdempotencyKey); err != nil {
+			return nil, err
+		}
+		return &webhook.Response{Success: true}, nil
+	}
+	request.Headers = endpoint.Headers
+	return r.WebhookClient.Send(endpoint.URL, request, delivery.Spec.IdempotencyKey)
+}
+
+func recordDeliveryMetrics(delivery *k8sv1.WebhookDelivery, delivered bool) {
+	var namespace, managedBy string
+	if delivery.Spec.Event != nil {
+		namespace = delivery.Spec.Event.Namespace
+		managedBy = delivery.Spec.Event.ManagedBy
+	}
+	sinkType := constants.NotificationSinkWebhook
+	if delivery.Spec.Sink != nil && delivery.Spec.Sink.Type != "" {
+		sinkType = delivery.Spec.Sink.Type
+	}
+	metrics.WebhookDeliveryAttemptsTotal.WithLabelValues(namespace, managedBy, sinkType).Inc()
+	if !delivered {
+		metrics.WebhookDeliveryFailuresTotal.WithLabelValues(namespace, managedBy, sinkType).Inc()
+	}
+}
+
+func getWebhookDeliveryBackoff(attempts int) time.Duration {
+	maxBackoff := constants.WebhookDeliveryMaxBackoffSeconds * time.Second
+	if attempts <= 0 {
+		return 0
+	}
+	if attempts > 10 {
+		return maxBackoff
+	}
+	backoff := time.Second << (attempts - 1)
+	if backoff > maxBackoff {
+		return maxBackoff
+	}
+	return backoff
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *WebhookDeliveryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.WebhookClient == nil {
+		r.WebhookClient = helpers.NewWebhookClient()
+		// The reconciler retries on its own schedule so a restart does not lose pending attempts.
+		r.WebhookClient.MaxRetries = 0
+	}
+	if r.EventPublishers == nil {
+		r.EventPublishers = helpers.NewEventPublishers()
+	}
+	// The connections to the message buses are kept open until the manager stops.
+	err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		r.EventPublishers.Close()
+		return nil
+	}))
+	if err != nil {
+		return err
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&k8sv1.WebhookDelivery{}).
+		Complete(r)
+}
@@ -0,0 +1,73 @@
+// Package netconfig holds the cluster network settings of the builds. The controller reads them from the
+// build defaults and passes them to the agent, both apply them to the pods they create.
+package netconfig
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	DefaultCABundleKey = "ca.crt"
+	CABundleVolumeName = "ca-bundle"
+	CABundleMountPath  = "/etc/humalect/ca-bundle"
+	CABundleFileName   = "ca.crt"
+)
+
+// Settings describe how the pods of a deployment reach git, the registries and the cloud APIs.
+type Settings struct {
+	// CABundleConfigMap is a ConfigMap of the humalect namespace with the PEM certificates of an internal
+	// CA. They are trusted in addition to the public CAs.
+	CABundleConfigMap string `json:"caBundleConfigMap,omitempty"`
+	// CABundleKey is the key of the certificates in the ConfigMap, ca.crt when empty.
+	CABundleKey string `json:"caBundleKey,omitempty"`
+	HTTPProxy   string `json:"httpProxy,omitempty"`
+	HTTPSProxy  string `json:"httpsProxy,omitempty"`
+	// NoProxy has to contain the Kubernetes API server when a proxy is set, the agent talks to it.
+	NoProxy string `json:"noProxy,omitempty"`
+	// InsecureRegistries are pulled from and pushed to over plain HTTP.
+	InsecureRegistries []string `json:"insecureRegistries,omitempty"`
+	// SkipTLSVerifyRegistries are reached over HTTPS without verifying their certificate.
+	SkipTLSVerifyRegistries []string `json:"skipTLSVerifyRegistries,omitempty"`
+}
+
+// GetProxyEnv returns the proxy variables in upper and lower case. Go reads either case, while curl, which
+// git uses for HTTPS remotes, only reads http_proxy in lowercase.
+func GetProxyEnv(network Settings) []corev1.EnvVar {
+	env := []corev1.EnvVar{}
+	for _, proxy := range []struct {
+		name  string
+		value string
+	}{
+		{"HTTP_PROXY", network.HTTPProxy},
+		{"HTTPS_PROXY", network.HTTPSProxy},
+		{"NO_PROXY", network.NoProxy},
+	} {
+		if proxy.value == "" {
+			continue
+		}
+		env = append(env,
+			corev1.EnvVar{Name: proxy.name, Value: proxy.value},
+			corev1.EnvVar{Name: strings.ToLower(proxy.name), Value: proxy.value},
+		)
+	}
+	return env
+}
+
+// GetCABundleVolume mounts the certificates of the CA bundle as ca.crt, whatever their key is.
+func GetCABundleVolume(network Settings) corev1.Volume {
+	key := network.CABundleKey
+	if key == "" {
+		key = DefaultCABundleKey
+	}
+	return corev1.Volume{
+		Name: CABundleVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: network.CABundleConfigMap},
+				Items:                []corev1.KeyToPath{{Key: key, Path: CABundleFileName}},
+			},
+		},
+	}
+}
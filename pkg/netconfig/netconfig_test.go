@@ -0,0 +1,29 @@
+package netconfig
+
+import "testing"
+
+func TestGetProxyEnv(t *testing.T) {
+	env := map[string]string{}
+	for _, envVar := range GetProxyEnv(Settings{HTTPProxy: "http://proxy.internal:3128", NoProxy: ".svc"}) {
+		env[envVar.Name] = envVar.Value
+	}
+	for _, name := range []string{"HTTP_PROXY", "http_proxy"} {
+		if env[name] != "http://proxy.internal:3128" {
+			t.Errorf("%s = %q, want the http proxy", name, env[name])
+		}
+	}
+	if env["NO_PROXY"] != ".svc" || env["no_proxy"] != ".svc" {
+		t.Errorf("no proxy = %q, %q", env["NO_PROXY"], env["no_proxy"])
+	}
+	if _, ok := env["HTTPS_PROXY"]; ok {
+		t.Error("HTTPS_PROXY is set without an https proxy")
+	}
+}
+
+func TestGetCABundleVolume(t *testing.T) {
+	volume := GetCABundleVolume(Settings{CABundleConfigMap: "internal-ca"})
+	items := volume.ConfigMap.Items
+	if volume.Name != CABundleVolumeName || volume.ConfigMap.Name != "internal-ca" || len(items) != 1 || items[0].Key != "ca.crt" || items[0].Path != "ca.crt" {
+		t.Errorf("CA bundle volume = %+v", volume)
+	}
+}
@@ -0,0 +1,102 @@
+// Package redact scrubs credentials and fetched secret values from everything written for a deployment,
+// like logs, webhook payloads, status messages and Kubernetes Events.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const Placeholder = "[REDACTED]"
+
+// Values shorter than this are not redacted, scrubbing values like "1" or "true" would mangle every message.
+const minSecretLength = 4
+
+// Redactor knows the secret values of a deployment. A nil Redactor leaves text as it is.
+type Redactor struct {
+	mutex  sync.RWMutex
+	values []string
+}
+
+func NewRedactor(values ...string) *Redactor {
+	redactor := &Redactor{}
+	redactor.Add(values...)
+	return redactor
+}
+
+// Add registers secret values. The JSON escaped form of a value is registered too, so the value is also
+// scrubbed from JSON encoded text.
+func (r *Redactor) Add(values ...string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, value := range values {
+		for _, variant := range []string{value, jsonEscape(value)} {
+			if len(variant) >= minSecretLength && !contains(r.values, variant) {
+				r.values = append(r.values, variant)
+			}
+		}
+	}
+	// Longer values go first so a secret that contains another one is scrubbed as a whole.
+	sort.SliceStable(r.values, func(i, j int) bool { return len(r.values[i]) > len(r.values[j]) })
+}
+
+// AddSecretData registers the values of a secret fetched from the secrets provider.
+func (r *Redactor) AddSecretData(secretData map[string]string) {
+	for _, value := range secretData {
+		r.Add(value)
+	}
+}
+
+// String replaces every registered secret value in the text with the placeholder.
+func (r *Redactor) String(text string) string {
+	if r == nil {
+		return text
+	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	for _, value := range r.values {
+		text = strings.ReplaceAll(text, value, Placeholder)
+	}
+	return text
+}
+
+// defaultRedactor is used by processes that run a single deployment, like the agent.
+var defaultRedactor = NewRedactor()
+
+// Add registers secret values with the default redactor.
+func Add(values ...string) {
+	defaultRedactor.Add(values...)
+}
+
+// AddSecretData registers the values of a fetched secret with the default redactor.
+func AddSecretData(secretData map[string]string) {
+	defaultRedactor.AddSecretData(secretData)
+}
+
+// String scrubs the secret values known to the default redactor from the text.
+func String(text string) string {
+	return defaultRedactor.String(text)
+}
+
+func jsonEscape(value string) string {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return value
+	}
+	escaped := strings.TrimSuffix(buffer.String(), "\n")
+	return escaped[1 : len(escaped)-1]
+}
+
+func contains(items []string, item string) bool {
+	for _, existing := range items {
+		if existing == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const deploymentSpanName = "deployment"
+
+type deploymentIdKey struct{}
+
+// deploymentIDGenerator derives the IDs of the root span of a deployment from its deploymentId, so the
+// span can be ended at the terminal event of the deployment by a process that did not start it. All other
+// spans get random IDs.
+type deploymentIDGenerator struct{}
+
+func (deploymentIDGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	if deploymentId, ok := ctx.Value(deploymentIdKey{}).(string); ok && deploymentId != "" {
+		return getDeploymentIDs(deploymentId)
+	}
+	traceID := trace.TraceID{}
+	_, _ = rand.Read(traceID[:])
+	return traceID, newSpanID()
+}
+
+func (deploymentIDGenerator) NewSpanID(ctx context.Context, traceID trace.TraceID) trace.SpanID {
+	return newSpanID()
+}
+
+func newSpanID() trace.SpanID {
+	spanID := trace.SpanID{}
+	_, _ = rand.Read(spanID[:])
+	return spanID
+}
+
+func getDeploymentIDs(deploymentId string) (trace.TraceID, trace.SpanID) {
+	traceID := trace.TraceID{}
+	spanID := trace.SpanID{}
+	traceSum := sha256.Sum256([]byte(deploymentId))
+	spanSum := sha256.Sum256([]byte("root/" + deploymentId))
+	copy(traceID[:], traceSum[:])
+	copy(spanID[:], spanSum[:])
+	return traceID, spanID
+}
+
+// ContextWithDeploymentTrace returns a context in the trace of the deployment, spans started from it become
+// children of the root span that EndDeploymentSpan exports once the deployment ends.
+func ContextWithDeploymentTrace(ctx context.Context, deploymentId string) context.Context {
+	if deploymentId == "" {
+		return ctx
+	}
+	traceID, spanID := getDeploymentIDs(deploymentId)
+	return trace.ContextWithRemoteSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	}))
+}
+
+// EndDeploymentSpan records the root span of the deployment from its start until now, it is called once at
+// the terminal event of the deployment.
+func EndDeploymentSpan(deploymentId string, start time.Time, err error, attrs ...attribute.KeyValue) {
+	if deploymentId == "" {
+		return
+	}
+	ctx := context.WithValue(context.Background(), deploymentIdKey{}, deploymentId)
+	_, span := StartSpan(ctx, deploymentSpanName, trace.WithNewRoot(), trace.WithTimestamp(start), trace.WithAttributes(attrs...))
+	EndSpan(span, err)
+}
@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceParentRoundTrip(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	ctx := ContextWithTraceParent(context.Background(), traceParent)
+	if got := GetTraceParent(ctx); got != traceParent {
+		t.Fatalf("GetTraceParent() = %q, want %q", got, traceParent)
+	}
+
+	// Without a tracer provider the child span keeps the trace of its parent.
+	ctx, span := StartSpan(ctx, "step")
+	defer span.End()
+	if got := span.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("trace id = %q, want the trace of the traceparent", got)
+	}
+	if got := GetTraceParent(ctx); got == "" {
+		t.Fatal("GetTraceParent() of the child span is empty")
+	}
+}
+
+func TestContextWithEmptyTraceParent(t *testing.T) {
+	if got := GetTraceParent(ContextWithTraceParent(context.Background(), "")); got != "" {
+		t.Fatalf("GetTraceParent() = %q, want empty", got)
+	}
+}
+
+func TestDeploymentSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder), sdktrace.WithIDGenerator(deploymentIDGenerator{}))
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	// The Job is created before the deployment ends, its span is exported first.
+	ctx := ContextWithDeploymentTrace(context.Background(), "dep-1")
+	_, span := StartSpan(ctx, "create-agent-job")
+	span.End()
+	start := time.Now().Add(-time.Minute)
+	EndDeploymentSpan("dep-1", start, errors.New("build failed"))
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	child, root := spans[0], spans[1]
+	if root.Name() != "deployment" || root.Parent().IsValid() {
+		t.Fatalf("root span %q has parent %v", root.Name(), root.Parent())
+	}
+	if child.SpanContext().TraceID() != root.SpanContext().TraceID() || child.Parent().SpanID() != root.SpanContext().SpanID() {
+		t.Errorf("span %q is not a child of the deployment span", child.Name())
+	}
+	if !root.StartTime().Equal(start) || root.Status().Code != codes.Error {
+		t.Errorf("deployment span started at %v with status %v", root.StartTime(), root.Status())
+	}
+
+	// Another deployment gets its own trace.
+	EndDeploymentSpan("dep-2", start, nil)
+	if other := recorder.Ended()[2]; other.SpanContext().TraceID() == root.SpanContext().TraceID() {
+		t.Error("deployments share a trace")
+	}
+}
@@ -0,0 +1,228 @@
+// Package webhook sends the webhooks of the controller and the agent.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	SignatureHeader   = "X-Humalect-Signature-256"
+	IdempotencyHeader = "Idempotency-Key"
+
+	DefaultTimeoutSeconds    = 10
+	DefaultMaxRetries        = 4
+	DefaultSigningSecretName = "humalect-webhook-signing"
+	DefaultSigningSecretKey  = "secret"
+	signingSecretRefresh     = 5 * time.Minute
+)
+
+// Request is a webhook body together with the headers it has to be sent with.
+type Request struct {
+	Payload []byte
+	Headers map[string]string
+}
+
+type Response struct {
+	Success bool
+	Status  int
+	Data    []byte
+}
+
+// Client posts webhook payloads. Network errors, 429 and 5xx responses are retried with an
+// exponential backoff, the payload is signed with HMAC-SHA256 when a signing secret is available.
+type Client struct {
+	HTTPClient     *http.Client
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	SigningSecret  func() []byte
+	// Log reports failed attempts. It takes key value pairs, like logr.Logger.Info and zap's Warnw.
+	Log func(msg string, keysAndValues ...interface{})
+}
+
+// NewClient returns a client configured from the WEBHOOK_TIMEOUT_SECONDS and WEBHOOK_MAX_RETRIES
+// environment variables.
+func NewClient(signingSecret func() []byte) *Client {
+	return &Client{
+		HTTPClient:     &http.Client{Timeout: time.Duration(getEnvInt("WEBHOOK_TIMEOUT_SECONDS", DefaultTimeoutSeconds)) * time.Second},
+		MaxRetries:     getEnvInt("WEBHOOK_MAX_RETRIES", DefaultMaxRetries),
+		InitialBackoff: time.Second,
+		MaxBackoff:     30 * time.Second,
+		SigningSecret:  signingSecret,
+	}
+}
+
+// IdempotencyKey is stable for a step of a deployment so receivers can drop retried deliveries.
+func IdempotencyKey(deploymentId string, step string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%s", deploymentId, step)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Sign returns the value of the signature header for the payload.
+func Sign(secret []byte, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (c *Client) Send(webhookEndpoint string, request Request, idempotencyKey string) (*Response, error) {
+	var signature string
+	if c.SigningSecret != nil {
+		if secret := c.SigningSecret(); len(secret) > 0 {
+			signature = Sign(secret, request.Payload)
+		}
+	}
+
+	var response *Response
+	var err error
+	for attempt := 0; attempt <= c.MaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(c.getBackoff(attempt))
+		}
+		response, err = c.post(webhookEndpoint, request, idempotencyKey, signature)
+		if err == nil && !IsRetryableStatus(response.Status) {
+			return response, nil
+		}
+		if err != nil {
+			c.log("Webhook attempt failed", "attempt", attempt+1, "error", err)
+		} else {
+			c.log("Webhook attempt failed", "attempt", attempt+1, "status", response.Status)
+		}
+	}
+	return response, err
+}
+
+func (c *Client) post(webhookEndpoint string, request Request, idempotencyKey string, signature string) (*Response, error) {
+	req, err := http.NewRequest(http.MethodPost, webhookEndpoint, bytes.NewReader(request.Payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range request.Headers {
+		req.Header.Set(key, value)
+	}
+	if idempotencyKey != "" {
+		req.Header.Set(IdempotencyHeader, idempotencyKey)
+	}
+	if signature != "" {
+		req.Header.Set(SignatureHeader, signature)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{
+		Success: resp.StatusCode >= 200 && resp.StatusCode < 300,
+		Status:  resp.StatusCode,
+		Data:    body,
+	}, nil
+}
+
+func (c *Client) getBackoff(attempt int) time.Duration {
+	backoff := c.InitialBackoff << (attempt - 1)
+	if backoff > c.MaxBackoff || backoff <= 0 {
+		return c.MaxBackoff
+	}
+	return backoff
+}
+
+func (c *Client) log(msg string, keysAndValues ...interface{}) {
+	if c.Log != nil {
+		c.Log(msg, keysAndValues...)
+	}
+}
+
+// IsRetryableStatus reports whether a delivery that got this status should be tried again.
+func IsRetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// SigningSecretRef points to the key of the Secret that webhook payloads are signed with.
+type SigningSecretRef struct {
+	Namespace string
+	Name      string
+	Key       string
+}
+
+// GetSigningSecretRef returns the Secret named by WEBHOOK_SIGNING_SECRET_NAME and WEBHOOK_SIGNING_SECRET_KEY
+// in the namespace, the humalect-webhook-signing Secret and its secret key when they are not set.
+func GetSigningSecretRef(namespace string) SigningSecretRef {
+	ref := SigningSecretRef{Namespace: namespace, Name: DefaultSigningSecretName, Key: DefaultSigningSecretKey}
+	if name := os.Getenv("WEBHOOK_SIGNING_SECRET_NAME"); name != "" {
+		ref.Name = name
+	}
+	if key := os.Getenv("WEBHOOK_SIGNING_SECRET_KEY"); key != "" {
+		ref.Key = key
+	}
+	return ref
+}
+
+// NewSigningSecretLoader returns the SigningSecret of a Client. A WEBHOOK_SIGNING_SECRET variable takes
+// precedence, otherwise the secret is read from the Secret and kept for a few minutes so rotated secrets are
+// picked up. Webhooks are sent unsigned when the Secret is missing.
+func NewSigningSecretLoader(ref SigningSecretRef, getConfig func() *rest.Config, log func(msg string, keysAndValues ...interface{})) func() []byte {
+	var (
+		secret   []byte
+		loadedAt time.Time
+		mutex    sync.Mutex
+	)
+	return func() []byte {
+		if value := os.Getenv("WEBHOOK_SIGNING_SECRET"); value != "" {
+			return []byte(value)
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		if time.Since(loadedAt) < signingSecretRefresh {
+			return secret
+		}
+
+		clientset, err := kubernetes.NewForConfig(getConfig())
+		if err != nil {
+			log("Error creating client to read webhook signing secret", "error", err)
+			return secret
+		}
+		signingSecret, err := clientset.CoreV1().Secrets(ref.Namespace).Get(context.TODO(), ref.Name, metav1.GetOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			log("Error reading webhook signing secret", "secret", ref.Name, "error", err)
+			return secret
+		}
+		secret = nil
+		if err == nil {
+			secret = signingSecret.Data[ref.Key]
+		}
+		loadedAt = time.Now()
+		return secret
+	}
+}
@@ -0,0 +1,100 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestClient(secret string) *Client {
+	return &Client{
+		HTTPClient:     &http.Client{Timeout: time.Second},
+		MaxRetries:     3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+		SigningSecret:  func() []byte { return []byte(secret) },
+	}
+}
+
+func TestClientRetriesServerErrors(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	response, err := newTestClient("").Send(server.URL, Request{Payload: []byte(`{}`)}, "key")
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if !response.Success || calls != 3 {
+		t.Fatalf("expected success after 3 calls, got success=%t calls=%d", response.Success, calls)
+	}
+}
+
+func TestClientDoesNotRetryClientErrors(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	response, err := newTestClient("").Send(server.URL, Request{Payload: []byte(`{}`)}, "key")
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if response.Success || calls != 1 {
+		t.Fatalf("expected a single failed call, got success=%t calls=%d", response.Success, calls)
+	}
+}
+
+func TestClientNetworkError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	response, err := newTestClient("").Send(server.URL, Request{Payload: []byte(`{}`)}, "key")
+	if err == nil || response != nil {
+		t.Fatalf("expected an error without response, got response=%v err=%v", response, err)
+	}
+}
+
+func TestClientHeaders(t *testing.T) {
+	payload := []byte(`{"type":"TYPE_DEPLOYMENT_STATUS_UPDATE"}`)
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+	}))
+	defer server.Close()
+
+	key := IdempotencyKey("dep-1", "DEPLOYMENT_COMPLETED")
+	if _, err := newTestClient("s3cr3t").Send(server.URL, Request{Payload: payload}, key); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if got := header.Get(IdempotencyHeader); got != key {
+		t.Fatalf("idempotency key = %q, want %q", got, key)
+	}
+	if got, want := header.Get(SignatureHeader), Sign([]byte("s3cr3t"), payload); got != want {
+		t.Fatalf("signature = %q, want %q", got, want)
+	}
+	if key == IdempotencyKey("dep-1", "DEPLOYMENT_FAILED") || key != IdempotencyKey("dep-1", "DEPLOYMENT_COMPLETED") {
+		t.Fatalf("idempotency key is not stable per deployment and step")
+	}
+}
+
+func TestGetSigningSecretRef(t *testing.T) {
+	if ref := GetSigningSecretRef("humalect"); ref != (SigningSecretRef{Namespace: "humalect", Name: DefaultSigningSecretName, Key: DefaultSigningSecretKey}) {
+		t.Fatalf("GetSigningSecretRef() = %+v, want the default Secret", ref)
+	}
+	t.Setenv("WEBHOOK_SIGNING_SECRET_NAME", "webhooks")
+	t.Setenv("WEBHOOK_SIGNING_SECRET_KEY", "hmac")
+	if ref := GetSigningSecretRef("humalect"); ref != (SigningSecretRef{Namespace: "humalect", Name: "webhooks", Key: "hmac"}) {
+		t.Fatalf("GetSigningSecretRef() = %+v, want the configured Secret", ref)
+	}
+}
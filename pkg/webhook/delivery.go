@@ -0,0 +1,110 @@
+package webhook
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// DeliveryGVR is the resource of the WebhookDelivery objects the controller posts until they are acknowledged.
+var DeliveryGVR = schema.GroupVersionResource{
+	Group:    "k8s.humalect.com",
+	Version:  "v1",
+	Resource: "webhookdeliveries",
+}
+
+// Event is the deployment step a delivery was recorded for, notification policies are matched against it.
+// It has the fields of the WebhookEvent of the controller API so the two convert into each other.
+type Event struct {
+	Namespace       string `json:"namespace,omitempty"`
+	DeploymentId    string `json:"deploymentId,omitempty"`
+	PipelineId      string `json:"pipelineId,omitempty"`
+	ManagedBy       string `json:"managedBy,omitempty"`
+	Step            string `json:"step"`
+	Success         bool   `json:"success"`
+	Error           string `json:"error,omitempty"`
+	Reason          string `json:"reason,omitempty"`
+	DurationSeconds int64  `json:"durationSeconds,omitempty"`
+	ElapsedSeconds  int64  `json:"elapsedSeconds,omitempty"`
+	CommitId        string `json:"commitId,omitempty"`
+	Image           string `json:"image,omitempty"`
+	ImageDigest     string `json:"imageDigest,omitempty"`
+	// Terminal marks the event that ends the deployment, the outcome of a deployment is counted at it.
+	Terminal bool `json:"terminal,omitempty"`
+}
+
+// NewEvent describes the step of the webhook data the notification policies are matched against.
+func NewEvent(data Data, success bool, step string, details StepDetails) Event {
+	event := Event{
+		Namespace:       data.Namespace,
+		DeploymentId:    data.DeploymentId,
+		PipelineId:      data.PipelineId,
+		ManagedBy:       data.ManagedBy,
+		Step:            step,
+		Success:         success,
+		Error:           details.Error,
+		Reason:          details.Reason,
+		DurationSeconds: int64(math.Round(details.DurationSeconds)),
+		CommitId:        data.CommitId,
+		Image:           data.Image,
+		ImageDigest:     data.ImageDigest,
+		Terminal:        details.Terminal,
+	}
+	if data.StartedAt != nil {
+		event.ElapsedSeconds = int64(time.Since(*data.StartedAt).Seconds())
+	}
+	return event
+}
+
+type deliverySpec struct {
+	Endpoint       string            `json:"endpoint,omitempty"`
+	Payload        string            `json:"payload"`
+	Headers        map[string]string `json:"headers,omitempty"`
+	IdempotencyKey string            `json:"idempotencyKey,omitempty"`
+	DeploymentId   string            `json:"deploymentId,omitempty"`
+	Step           string            `json:"step,omitempty"`
+	Event          *Event            `json:"event,omitempty"`
+}
+
+// GetDeliveryName is derived from the payload so the same event recorded twice maps to one delivery.
+func GetDeliveryName(idempotencyKey string, payload []byte) string {
+	sum := sha256.Sum256(append([]byte(idempotencyKey+"\n"), payload...))
+	return "webhook-" + hex.EncodeToString(sum[:])[:20]
+}
+
+// NewDelivery builds the WebhookDelivery that records the webhook of the event. The controller posts it to
+// webhookEndpoint, when set, and to every notification policy sink that matches the event.
+func NewDelivery(namespace string, webhookEndpoint string, request Request, idempotencyKey string, event Event) (*unstructured.Unstructured, error) {
+	spec, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&deliverySpec{
+		Endpoint:       webhookEndpoint,
+		Payload:        string(request.Payload),
+		Headers:        request.Headers,
+		IdempotencyKey: idempotencyKey,
+		DeploymentId:   event.DeploymentId,
+		Step:           event.Step,
+		Event:          &event,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	labels := map[string]string{
+		"managedBy":    "humalect",
+		"resourceType": "webhook-delivery",
+	}
+	if len(validation.IsValidLabelValue(event.DeploymentId)) == 0 {
+		labels["deploymentId"] = event.DeploymentId
+	}
+	delivery := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	delivery.SetGroupVersionKind(DeliveryGVR.GroupVersion().WithKind("WebhookDelivery"))
+	delivery.SetName(GetDeliveryName(idempotencyKey, request.Payload))
+	delivery.SetNamespace(namespace)
+	delivery.SetLabels(labels)
+	return delivery, nil
+}
@@ -0,0 +1,42 @@
+package webhook
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestNewDelivery(t *testing.T) {
+	request := Request{Payload: []byte(`{"step":"BUILD"}`), Headers: map[string]string{"Content-Type": "application/json"}}
+	event := Event{DeploymentId: "dep-1", Step: "BUILD", Success: true, DurationSeconds: 12}
+
+	delivery, err := NewDelivery("humalect", "https://example.com/hook", request, "key", event)
+	if err != nil {
+		t.Fatalf("NewDelivery() error = %v", err)
+	}
+	if delivery.GetName() != GetDeliveryName("key", request.Payload) || delivery.GetNamespace() != "humalect" {
+		t.Errorf("NewDelivery() = %s/%s, want the delivery name in the humalect namespace", delivery.GetNamespace(), delivery.GetName())
+	}
+	if delivery.GetLabels()["deploymentId"] != "dep-1" {
+		t.Errorf("labels = %v, want the deploymentId label", delivery.GetLabels())
+	}
+	if step, _, _ := unstructured.NestedString(delivery.Object, "spec", "event", "step"); step != "BUILD" {
+		t.Errorf("spec.event.step = %q, want BUILD", step)
+	}
+	if duration, _, _ := unstructured.NestedInt64(delivery.Object, "spec", "event", "durationSeconds"); duration != 12 {
+		t.Errorf("spec.event.durationSeconds = %d, want 12", duration)
+	}
+	if header, _, _ := unstructured.NestedString(delivery.Object, "spec", "headers", "Content-Type"); header != "application/json" {
+		t.Errorf("spec.headers = %v, want the request headers", delivery.Object["spec"])
+	}
+
+	// A deployment id that is not a valid label value is only kept in the spec.
+	event.DeploymentId = "not a label/value"
+	delivery, err = NewDelivery("humalect", "", request, "key", event)
+	if err != nil {
+		t.Fatalf("NewDelivery() error = %v", err)
+	}
+	if _, ok := delivery.GetLabels()["deploymentId"]; ok {
+		t.Errorf("labels = %v, want no deploymentId label", delivery.GetLabels())
+	}
+}
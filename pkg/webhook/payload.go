@@ -0,0 +1,214 @@
+package webhook
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+const (
+	FormatLegacy          = "legacy"
+	FormatCloudEvents     = "cloudevents"
+	ContentModeStructured = "structured"
+	ContentModeBinary     = "binary"
+	CloudEventTypePrefix  = "com.humalect.deployment.step."
+
+	TypeDeploymentStatusUpdate = "TYPE_DEPLOYMENT_STATUS_UPDATE"
+	StatusDeploymentFailed     = "DEPLOYMENT_FAILED"
+)
+
+// Data is the webhook data carried in the spec of Applications and DeploymentSets and passed on to the
+// agent. QueueName and State are set by the backend that started the deployment and are passed back as
+// received.
+type Data struct {
+	QueueName          json.RawMessage `json:"queueName,omitempty"`
+	State              json.RawMessage `json:"state,omitempty"`
+	StatusData         map[string]bool `json:"statusData,omitempty"`
+	DeploymentId       string          `json:"deploymentId,omitempty"`
+	PipelineId         string          `json:"pipelineId,omitempty"`
+	ManagedBy          string          `json:"managedBy,omitempty"`
+	Namespace          string          `json:"namespace,omitempty"`
+	StartedAt          *time.Time      `json:"startedAt,omitempty"`
+	Diff               []ResourceDiff  `json:"diff,omitempty"`
+	FreezeReason       string          `json:"freezeReason,omitempty"`
+	WebhookFormat      string          `json:"webhookFormat,omitempty"`
+	WebhookContentMode string          `json:"webhookContentMode,omitempty"`
+	Artifacts
+}
+
+// ResourceDiff describes how a live resource differs from the desired one. It has the fields of the
+// ResourceDiff of the controller API.
+type ResourceDiff struct {
+	Kind        string   `json:"kind"`
+	Name        string   `json:"name"`
+	Action      string   `json:"action"`
+	Fields      []string `json:"fields,omitempty"`
+	AddedKeys   []string `json:"addedKeys,omitempty"`
+	RemovedKeys []string `json:"removedKeys,omitempty"`
+	ChangedKeys []string `json:"changedKeys,omitempty"`
+}
+
+// Artifacts describes what the deployment built. It is collected by the agent and sent with every later
+// step.
+type Artifacts struct {
+	CommitId      string `json:"commitId,omitempty"`
+	Image         string `json:"image,omitempty"`
+	ImageDigest   string `json:"imageDigest,omitempty"`
+	KanikoJobName string `json:"kanikoJobName,omitempty"`
+	KanikoPodName string `json:"kanikoPodName,omitempty"`
+}
+
+// StepDetails describes the outcome of a single step and is only sent with the event of that step.
+type StepDetails struct {
+	Error           string  `json:"error,omitempty"`
+	Reason          string  `json:"reason,omitempty"`
+	DurationSeconds float64 `json:"durationSeconds,omitempty"`
+	LogExcerpt      string  `json:"logExcerpt,omitempty"`
+	// ContainerReason is the reason the failed container terminated with, like OOMKilled.
+	ContainerReason string `json:"containerReason,omitempty"`
+	// Terminal is set on the last event of a deployment, it is only passed on to the recorded event.
+	Terminal bool `json:"-"`
+}
+
+// LegacyPayload is the {type, data} payload sent when the webhook format is legacy.
+type LegacyPayload struct {
+	Type string            `json:"type"`
+	Data LegacyPayloadData `json:"data"`
+}
+
+// LegacyPayloadData keeps the original queueName, statusData, state and status fields, everything else is
+// optional so existing consumers are not affected.
+type LegacyPayloadData struct {
+	QueueName      json.RawMessage `json:"queueName"`
+	StatusData     map[string]bool `json:"statusData"`
+	State          json.RawMessage `json:"state"`
+	Status         string          `json:"status"`
+	Step           string          `json:"step,omitempty"`
+	DeploymentId   string          `json:"deploymentId,omitempty"`
+	ElapsedSeconds float64         `json:"elapsedSeconds,omitempty"`
+	Diff           []ResourceDiff  `json:"diff,omitempty"`
+	FreezeReason   string          `json:"freezeReason,omitempty"`
+	Artifacts
+	StepDetails
+}
+
+// CloudEvent is a CloudEvents 1.0 event in structured mode. In binary mode the attributes are sent as
+// ce- headers and only Data is sent as the body.
+type CloudEvent struct {
+	SpecVersion     string                  `json:"specversion"`
+	ID              string                  `json:"id"`
+	Source          string                  `json:"source"`
+	Type            string                  `json:"type"`
+	Subject         string                  `json:"subject,omitempty"`
+	Time            time.Time               `json:"time"`
+	DataContentType string                  `json:"datacontenttype"`
+	Data            DeploymentStepEventData `json:"data"`
+}
+
+// DeploymentStepEventData is the data of the com.humalect.deployment.step.* events.
+type DeploymentStepEventData struct {
+	DeploymentId   string          `json:"deploymentId,omitempty"`
+	Step           string          `json:"step"`
+	Success        bool            `json:"success"`
+	Status         string          `json:"status"`
+	QueueName      json.RawMessage `json:"queueName,omitempty"`
+	State          json.RawMessage `json:"state,omitempty"`
+	StatusData     map[string]bool `json:"statusData,omitempty"`
+	ElapsedSeconds float64         `json:"elapsedSeconds,omitempty"`
+	Diff           []ResourceDiff  `json:"diff,omitempty"`
+	FreezeReason   string          `json:"freezeReason,omitempty"`
+	Artifacts
+	StepDetails
+}
+
+// GetCloudEventType maps a step like PRE_DEPLOY_HOOK_EXECUTED:migrate to com.humalect.deployment.step.pre_deploy_hook_executed.
+func GetCloudEventType(step string) string {
+	return CloudEventTypePrefix + strings.ToLower(strings.SplitN(step, ":", 2)[0])
+}
+
+// BuildRequest renders the webhook for a step in the format selected in the webhook data. Source is the
+// CloudEvents source of the controller or the agent.
+func BuildRequest(data Data, success bool, step string, details StepDetails, source string) (Request, error) {
+	status := step
+	if !success {
+		status = StatusDeploymentFailed
+	}
+	var elapsedSeconds float64
+	if data.StartedAt != nil {
+		elapsedSeconds = time.Since(*data.StartedAt).Round(time.Second).Seconds()
+	}
+
+	if data.WebhookFormat != FormatCloudEvents {
+		payload, err := json.Marshal(LegacyPayload{
+			Type: TypeDeploymentStatusUpdate,
+			Data: LegacyPayloadData{
+				QueueName:      nullIfEmpty(data.QueueName),
+				StatusData:     data.StatusData,
+				State:          nullIfEmpty(data.State),
+				Status:         status,
+				Step:           step,
+				DeploymentId:   data.DeploymentId,
+				ElapsedSeconds: elapsedSeconds,
+				Diff:           data.Diff,
+				FreezeReason:   data.FreezeReason,
+				Artifacts:      data.Artifacts,
+				StepDetails:    details,
+			},
+		})
+		return Request{Payload: payload, Headers: map[string]string{"Content-Type": "application/json"}}, err
+	}
+
+	event := CloudEvent{
+		SpecVersion:     "1.0",
+		ID:              IdempotencyKey(data.DeploymentId, step),
+		Source:          source,
+		Type:            GetCloudEventType(step),
+		Subject:         data.DeploymentId,
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		Data: DeploymentStepEventData{
+			DeploymentId:   data.DeploymentId,
+			Step:           step,
+			Success:        success,
+			Status:         status,
+			QueueName:      data.QueueName,
+			State:          data.State,
+			StatusData:     data.StatusData,
+			ElapsedSeconds: elapsedSeconds,
+			Diff:           data.Diff,
+			FreezeReason:   data.FreezeReason,
+			Artifacts:      data.Artifacts,
+			StepDetails:    details,
+		},
+	}
+	if data.WebhookContentMode == ContentModeBinary {
+		payload, err := json.Marshal(event.Data)
+		headers := map[string]string{
+			"Content-Type":   event.DataContentType,
+			"ce-specversion": event.SpecVersion,
+			"ce-id":          event.ID,
+			"ce-source":      event.Source,
+			"ce-type":        event.Type,
+			"ce-time":        event.Time.Format(time.RFC3339Nano),
+		}
+		if event.Subject != "" {
+			headers["ce-subject"] = event.Subject
+		}
+		return Request{Payload: payload, Headers: headers}, err
+	}
+	payload, err := json.Marshal(event)
+	return Request{Payload: payload, Headers: map[string]string{"Content-Type": "application/cloudevents+json"}}, err
+}
+
+// SecondsSince is the duration of a step that started at start, as sent in the webhook.
+func SecondsSince(start time.Time) float64 {
+	return time.Since(start).Round(time.Millisecond).Seconds()
+}
+
+// nullIfEmpty keeps the legacy payload sending null for fields the backend did not set.
+func nullIfEmpty(value json.RawMessage) json.RawMessage {
+	if len(value) == 0 {
+		return json.RawMessage("null")
+	}
+	return value
+}
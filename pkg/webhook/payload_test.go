@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+const testSource = "/humalect-core/controller"
+
+func TestBuildRequestLegacy(t *testing.T) {
+	var webhookData Data
+	if err := json.Unmarshal([]byte(`{"queueName":"deployments","state":"running","deploymentId":"dep-1"}`), &webhookData); err != nil {
+		t.Fatal(err)
+	}
+	request, err := BuildRequest(webhookData, false, "DEPLOYMENT_COMPLETED", StepDetails{}, testSource)
+	if err != nil {
+		t.Fatalf("BuildRequest() error = %v", err)
+	}
+	want := `{"type":"TYPE_DEPLOYMENT_STATUS_UPDATE","data":{"queueName":"deployments","statusData":null,"state":"running","status":"DEPLOYMENT_FAILED","step":"DEPLOYMENT_COMPLETED","deploymentId":"dep-1"}}`
+	if string(request.Payload) != want {
+		t.Fatalf("payload = %s, want %s", request.Payload, want)
+	}
+}
+
+func TestBuildRequestCloudEvents(t *testing.T) {
+	webhookData := Data{DeploymentId: "dep-1", WebhookFormat: FormatCloudEvents}
+	step := "PRE_DEPLOY_HOOK_EXECUTED" + ":migrate"
+
+	request, err := BuildRequest(webhookData, true, step, StepDetails{}, testSource)
+	if err != nil {
+		t.Fatalf("BuildRequest() error = %v", err)
+	}
+	if request.Headers["Content-Type"] != "application/cloudevents+json" {
+		t.Fatalf("structured mode content type = %q", request.Headers["Content-Type"])
+	}
+	var event CloudEvent
+	if err := json.Unmarshal(request.Payload, &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.SpecVersion != "1.0" || event.Type != "com.humalect.deployment.step.pre_deploy_hook_executed" || event.Data.Step != step || !event.Data.Success {
+		t.Fatalf("unexpected event %+v", event)
+	}
+
+	webhookData.WebhookContentMode = ContentModeBinary
+	request, err = BuildRequest(webhookData, true, step, StepDetails{}, testSource)
+	if err != nil {
+		t.Fatalf("BuildRequest() error = %v", err)
+	}
+	if request.Headers["ce-type"] != event.Type || request.Headers["ce-id"] != event.ID || request.Headers["Content-Type"] != "application/json" {
+		t.Fatalf("unexpected binary mode headers %v", request.Headers)
+	}
+	var data DeploymentStepEventData
+	if err := json.Unmarshal(request.Payload, &data); err != nil || data.DeploymentId != "dep-1" {
+		t.Fatalf("unexpected binary mode body %s", request.Payload)
+	}
+}
+
+func TestBuildRequestDetails(t *testing.T) {
+	startedAt := time.Now().Add(-90 * time.Second)
+	webhookData := Data{DeploymentId: "dep-1", StartedAt: &startedAt, FreezeReason: "Release week", Artifacts: Artifacts{CommitId: "abc123", ImageDigest: "sha256:feed"}}
+	details := StepDetails{Error: "hook exited with 1", Reason: "HookFailed", DurationSeconds: 12, LogExcerpt: "migration failed", ContainerReason: "OOMKilled"}
+
+	request, err := BuildRequest(webhookData, false, "POST_DEPLOY_HOOK_EXECUTED", details, testSource)
+	if err != nil {
+		t.Fatalf("BuildRequest() error = %v", err)
+	}
+	var payload LegacyPayload
+	if err := json.Unmarshal(request.Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+	data := payload.Data
+	if data.Error != details.Error || data.Reason != details.Reason || data.DurationSeconds != 12 || data.LogExcerpt != details.LogExcerpt || data.ContainerReason != "OOMKilled" {
+		t.Fatalf("unexpected details %+v", data)
+	}
+	if data.CommitId != "abc123" || data.ImageDigest != "sha256:feed" || data.FreezeReason != "Release week" || data.ElapsedSeconds < 90 {
+		t.Fatalf("unexpected artifacts %+v", data)
+	}
+}